@@ -14,6 +14,27 @@ type GameDefinitionInput struct {
 	Default     AnyVal `json:"default,omitempty"`
 	Description string `json:"description,omitempty"`
 	Type        string `json:"type,omitempty" default:"string"`
+	// Enum restricts the input to one of these values.
+	// +kubebuilder:validation:XPreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Enum []AnyVal `json:"enum,omitempty"`
+	// Minimum is the smallest allowed value of a number input.
+	// +kubebuilder:validation:XPreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Minimum *AnyVal `json:"minimum,omitempty"`
+	// Maximum is the largest allowed value of a number input.
+	// +kubebuilder:validation:XPreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Maximum *AnyVal `json:"maximum,omitempty"`
+	// Pattern is a regular expression a string input must match. Like in OpenAPI it isn't anchored,
+	// use ^ and $ to match the whole value.
+	Pattern string `json:"pattern,omitempty"`
+	// MinLength is the minimum number of characters of a string input.
+	// +kubebuilder:validation:Minimum=0
+	MinLength *int64 `json:"minLength,omitempty"`
+	// MaxLength is the maximum number of characters of a string input.
+	// +kubebuilder:validation:Minimum=0
+	MaxLength *int64 `json:"maxLength,omitempty"`
 }
 
 // GamePort defines networking ports.
@@ -23,7 +44,63 @@ type GamePort struct {
 	Name          string             `json:"name"`
 	ContainerPort intstr.IntOrString `json:"containerPort"` // can be int or string
 	Protocol      string             `json:"protocol,omitempty"`
-	Type          string             `json:"type,omitempty"` // HostPort | NodePort | etc.
+	// Type is how the port is exposed: HostPort, NodePort, ClusterIP or LoadBalancer. Ports of the last three
+	// types are grouped into one Service per type, LoadBalancer ports into one per protocol.
+	Type string `json:"type,omitempty"`
+}
+
+// LoadBalancerConfig configures the Services created for LoadBalancer ports.
+type LoadBalancerConfig struct {
+	// Annotations are added to the LoadBalancer Services, e.g. to pick a MetalLB address pool.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FileBrowserConfig configures the filebrowser sidecar.
+type FileBrowserConfig struct {
+	// Image is the filebrowser image including its tag. It must contain sh and the filebrowser binary.
+	Image string `json:"image,omitempty"`
+	// ReadOnly mounts the game data read-only and only gives the admin user read permissions.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Resources of the filebrowser container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// FileBrowserRouteKind is the kind of object routing /files/<id> to filebrowser.
+// +kubebuilder:validation:Enum=None;Ingress;HTTPRoute
+type FileBrowserRouteKind string
+
+const (
+	FileBrowserRouteNone      FileBrowserRouteKind = "None"
+	FileBrowserRouteIngress   FileBrowserRouteKind = "Ingress"
+	FileBrowserRouteHTTPRoute FileBrowserRouteKind = "HTTPRoute"
+)
+
+// FileBrowserRouteConfig configures how filebrowser is exposed at /files/<id>. Unset fields fall back to the
+// operator's --filebrowser-* flags.
+type FileBrowserRouteConfig struct {
+	// Kind selects an Ingress or a Gateway API HTTPRoute, or None to only create the ClusterIP Service.
+	Kind FileBrowserRouteKind `json:"kind,omitempty"`
+	// Host is the host name filebrowser is served on. Without it every host matches.
+	Host string `json:"host,omitempty"`
+	// Annotations are added to the Ingress or HTTPRoute.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// IngressClassName selects the Ingress controller.
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// TLSSecretName is the Secret with the certificate for Host, used by Ingresses.
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// ClusterIssuer is the cert-manager ClusterIssuer that issues the certificate of an Ingress into TLSSecretName.
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+	// Gateway is the Gateway an HTTPRoute attaches to. TLS of HTTPRoutes is terminated by its listeners.
+	Gateway *GatewayReference `json:"gateway,omitempty"`
+}
+
+// GatewayReference points at a Gateway API Gateway.
+type GatewayReference struct {
+	Name string `json:"name"`
+	// Namespace defaults to the GameServer's namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName selects a listener of the Gateway.
+	SectionName string `json:"sectionName,omitempty"`
 }
 
 // StopStrategy controls how the game server is shut down
@@ -33,6 +110,14 @@ type StopStrategy struct {
 	ShutdownGracePeriod string   `json:"shutdownGracePeriod,omitempty"`
 }
 
+// BackupStrategy controls how the game is told to save its data before a backup
+type BackupStrategy struct {
+	Stdin string   `json:"stdin,omitempty"`
+	Cmd   []string `json:"cmd,omitempty"`
+	// FlushDelay is how long to wait after the save before copying the data, 5s by default.
+	FlushDelay string `json:"flushDelay,omitempty"`
+}
+
 // RestartStrategy controls how to restart the server
 type RestartStrategy struct {
 	Cmd []string `json:"cmd,omitempty"`
@@ -40,10 +125,130 @@ type RestartStrategy struct {
 
 // StorageConfig describes persistent storage options
 type StorageConfig struct {
+	// Enabled keeps the game data on a PersistentVolumeClaim. Otherwise it lives in an emptyDir and is lost
+	// whenever the Pod is recreated.
 	// +kubebuilder:validation:XPreserveUnknownFields
 	// +kubebuilder:validation:Schemaless
 	Enabled     BoolOrString `json:"enabled"`
-	DefaultSize string       `json:"defaultSize" default:"10Gi"`
+	DefaultSize string       `json:"defaultSize,omitempty" default:"10Gi"`
+	// SizeLimit caps the emptyDir used when storage isn't enabled.
+	SizeLimit string `json:"sizeLimit,omitempty"`
+	// RetainPolicy decides what happens to the claim when the GameServer is deleted: Delete (the default),
+	// Retain or Snapshot.
+	RetainPolicy RetainPolicy `json:"retainPolicy,omitempty"`
+	// SnapshotClassName is the VolumeSnapshotClass of the final snapshot taken with retainPolicy Snapshot. The
+	// cluster's default class is used if empty.
+	SnapshotClassName string `json:"snapshotClassName,omitempty"`
+	// Backup takes GameServerBackups of the game data on a schedule. GameServers can override it in spec.backup.
+	Backup *BackupSchedule `json:"backup,omitempty"`
+
+	VolumeClaimOptions `json:",inline"`
+}
+
+// BackupSchedule takes a GameServerBackup of a GameServer periodically and prunes the older ones.
+type BackupSchedule struct {
+	// Schedule in cron syntax, e.g. "0 4 * * *" or "@daily". Times are UTC. A backup that can't start within
+	// an hour of its time is skipped and reported as missed.
+	Schedule string `json:"schedule"`
+	// SaveCommand is sent to the game's stdin before each backup, see GameServerBackupSpec.
+	SaveCommand string `json:"saveCommand,omitempty"`
+	// Target is where the backups are stored. A VolumeSnapshot with the cluster's default class if empty.
+	Target BackupTarget `json:"target,omitempty"`
+	// Retention decides which scheduled backups are kept.
+	Retention BackupRetention `json:"retention,omitempty"`
+}
+
+// BackupRetention keeps the newest backups plus daily, weekly and monthly generations. A backup is kept if any
+// of the rules keeps it, all others are pruned once a newer backup completed. Without any rule the last 7
+// backups are kept.
+type BackupRetention struct {
+	// KeepLast keeps the most recent backups.
+	// +kubebuilder:validation:Minimum=0
+	KeepLast int32 `json:"keepLast,omitempty"`
+	// KeepDaily keeps the newest backup of each of the most recent days.
+	// +kubebuilder:validation:Minimum=0
+	KeepDaily int32 `json:"keepDaily,omitempty"`
+	// KeepWeekly keeps the newest backup of each of the most recent ISO weeks.
+	// +kubebuilder:validation:Minimum=0
+	KeepWeekly int32 `json:"keepWeekly,omitempty"`
+	// KeepMonthly keeps the newest backup of each of the most recent months.
+	// +kubebuilder:validation:Minimum=0
+	KeepMonthly int32 `json:"keepMonthly,omitempty"`
+}
+
+// RetainPolicy describes what happens to the game data claim when its GameServer is deleted.
+type RetainPolicy string
+
+const (
+	// RetainPolicyDelete deletes the claim together with the GameServer.
+	RetainPolicyDelete RetainPolicy = "Delete"
+	// RetainPolicyRetain keeps the claim. A new GameServer with the same kraftnetes-id adopts it.
+	RetainPolicyRetain RetainPolicy = "Retain"
+	// RetainPolicySnapshot takes a VolumeSnapshot of the claim before it is deleted. If the snapshot fails, the
+	// claim is retained instead.
+	RetainPolicySnapshot RetainPolicy = "Snapshot"
+)
+
+// VolumeClaimOptions configure the PersistentVolumeClaim of the game data and what it starts out with. They only
+// apply when the claim is created, Kubernetes doesn't allow changing them afterwards.
+type VolumeClaimOptions struct {
+	// StorageClassName of the claim. The cluster's default StorageClass is used if empty.
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// AccessModes of the claim, ReadWriteOnce if empty.
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// VolumeMode of the claim, Filesystem if empty. The game data is mounted at /data, so Block volumes are
+	// rejected.
+	// +kubebuilder:validation:Enum=Filesystem;Block
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+	// Seed fills the game data before the game first starts, e.g. with a pre-generated world. Data that
+	// isn't empty is never touched.
+	Seed *SeedSource `json:"seed,omitempty"`
+}
+
+// SeedSource is where the initial game data comes from. Exactly one source must be set. Archives may be tar
+// files, optionally gzip compressed, or zip files.
+type SeedSource struct {
+	// HTTP downloads an archive.
+	HTTP *HTTPSeedSource `json:"http,omitempty"`
+	// ConfigMap copies the files of a ConfigMap in the GameServer's namespace.
+	ConfigMap *ObjectSeedSource `json:"configMap,omitempty"`
+	// Secret copies the files of a Secret in the GameServer's namespace.
+	Secret *ObjectSeedSource `json:"secret,omitempty"`
+	// PVC copies the contents of another PersistentVolumeClaim in the GameServer's namespace. The claim is
+	// mounted read-only, so it must allow that next to any other user.
+	PVC *PVCSeedSource `json:"pvc,omitempty"`
+}
+
+// HTTPSeedSource downloads an archive over HTTP or HTTPS.
+type HTTPSeedSource struct {
+	URL string `json:"url"`
+	// SHA256 is the hex encoded checksum the download must match.
+	SHA256 string `json:"sha256"`
+}
+
+// ObjectSeedSource references a ConfigMap or Secret. Without a key every key is copied as a file, with a key
+// that key holds an archive to extract.
+type ObjectSeedSource struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+// PVCSeedSource copies the directory at path of a claim, its root if empty.
+type PVCSeedSource struct {
+	ClaimName string `json:"claimName"`
+	Path      string `json:"path,omitempty"`
+}
+
+// Mod is a file downloaded into the game data, like a plugin jar. Files of mods that are no longer listed are
+// removed again, other files in the game data are left alone.
+type Mod struct {
+	// Name identifies the mod, profiles and GameServers replace or remove mods by name.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// SHA256 is the hex encoded checksum of the file. The game doesn't start if the download doesn't match.
+	SHA256 string `json:"sha256"`
+	// Path is the file path below /data, e.g. plugins/EssentialsX.jar.
+	Path string `json:"path"`
 }
 
 // GameProfile defines an override profile for a GameDefinition.
@@ -51,14 +256,17 @@ type StorageConfig struct {
 type GameProfile struct {
 	Name  string `json:"name"`
 	Image string `json:"image,omitempty"`
+	// FileBrowser overrides the flag of the GameDefinition if set.
 	// +kubebuilder:validation:XPreserveUnknownFields
 	// +kubebuilder:validation:Schemaless
-	FileBrowser     BoolOrString     `json:"filebrowser,omitempty"`
+	FileBrowser     *BoolOrString    `json:"filebrowser,omitempty"`
 	StopStrategy    *StopStrategy    `json:"stopStrategy,omitempty"`
 	RestartStrategy *RestartStrategy `json:"restartStrategy,omitempty"`
 	Storage         *StorageConfig   `json:"storage,omitempty"`
 	Ports           []GamePort       `json:"ports,omitempty"`
 	Env             []corev1.EnvVar  `json:"env,omitempty"`
+	// Mods are added to those of the GameDefinition, replacing mods of the same name.
+	Mods []Mod `json:"mods,omitempty"`
 }
 
 // GameProfiles allows optional predefined profiles
@@ -80,16 +288,41 @@ type GameDefinitionSpec struct {
 	Ports           []GamePort       `json:"ports,omitempty"`
 	Env             []corev1.EnvVar  `json:"env,omitempty"`
 	Profiles        *GameProfiles    `json:"profiles,omitempty"`
+	// Mods are files like plugin jars that are synced into the game data before the game starts.
+	Mods []Mod `json:"mods,omitempty"`
+	// BackupStrategy tells the game to save before a GameServerBackup copies its data.
+	BackupStrategy *BackupStrategy `json:"backupStrategy,omitempty"`
+	// LoadBalancer configures the Services of LoadBalancer ports.
+	LoadBalancer *LoadBalancerConfig `json:"loadBalancer,omitempty"`
+	// FileBrowserConfig configures the filebrowser sidecar enabled by FileBrowser.
+	FileBrowserConfig *FileBrowserConfig `json:"fileBrowserConfig,omitempty"`
+	// FileBrowserRoute configures the Ingress or HTTPRoute of filebrowser.
+	FileBrowserRoute *FileBrowserRouteConfig `json:"fileBrowserRoute,omitempty"`
 }
 
+// ConditionValid reports whether a GameDefinition, or a GameServer against its GameDefinition, passed validation.
+const ConditionValid = "Valid"
+
 // GameDefinitionStatus defines the observed state of GameDefinition
 type GameDefinitionStatus struct {
-	// ...
+	// ObservedGeneration is the most recent generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// GameServerCount is the number of GameServers referencing this definition.
+	GameServerCount int `json:"gameServerCount"`
+	// GameServers lists the GameServers referencing this definition as namespace/name.
+	GameServers []string `json:"gameServers,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Game",type=string,JSONPath=`.spec.game`
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+// +kubebuilder:printcolumn:name="GameServers",type=integer,JSONPath=`.status.gameServerCount`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // GameDefinition is the Schema for the gamedefinitions API.
 type GameDefinition struct {
@@ -15,16 +15,210 @@ type GameServerSpec struct {
 	Filebrowser *bool                           `json:"filebrowser,omitempty"`
 	Env         []corev1.EnvVar                 `json:"env,omitempty"`
 	Resources   corev1.ResourceRequirements     `json:"resources,omitempty"`
+	// Storage overrides the claim options of the GameDefinition for this GameServer.
+	Storage *VolumeClaimOptions `json:"storage,omitempty"`
+	// Backup overrides the backup schedule of the GameDefinition's storage for this GameServer.
+	Backup *BackupSchedule `json:"backup,omitempty"`
+	// RestoreFrom replaces the game data with a backup. The game is stopped gracefully, the data restored by an
+	// init container and the game started again. Set at creation, it seeds the new GameServer with the backup.
+	RestoreFrom *RestoreSource `json:"restoreFrom,omitempty"`
+	// Mods are added to those of the GameDefinition and profile, replacing mods of the same name.
+	Mods []Mod `json:"mods,omitempty"`
+	// RemoveMods names mods of the GameDefinition or profile this GameServer goes without.
+	RemoveMods []string `json:"removeMods,omitempty"`
+	// RestartRequestedAt requests a restart of the game server. Setting it to a time later than
+	// status.lastRestart.requestedAt triggers a new restart, same as the restart-requested-at annotation.
+	RestartRequestedAt *metav1.Time `json:"restartRequestedAt,omitempty"`
+}
+
+// RestartMethod describes how a restart was carried out.
+type RestartMethod string
+
+const (
+	// RestartMethodCmd means the RestartStrategy command was run inside the game container.
+	RestartMethodCmd RestartMethod = "Cmd"
+	// RestartMethodRecreate means the game was stopped gracefully and its Pod recreated.
+	RestartMethodRecreate RestartMethod = "Recreate"
+)
+
+// RestartResult is the outcome of a restart.
+type RestartResult string
+
+const (
+	RestartInProgress RestartResult = "InProgress"
+	RestartSucceeded  RestartResult = "Succeeded"
+	RestartFailed     RestartResult = "Failed"
+)
+
+// RestartStatus records the most recent restart of a GameServer.
+type RestartStatus struct {
+	RequestedAt metav1.Time   `json:"requestedAt"`
+	CompletedAt *metav1.Time  `json:"completedAt,omitempty"`
+	Method      RestartMethod `json:"method,omitempty"`
+	Result      RestartResult `json:"result,omitempty"`
+	Message     string        `json:"message,omitempty"`
+}
+
+// RestoreSource is a backup to restore the game data from.
+type RestoreSource struct {
+	// Backup is the name of a completed GameServerBackup in the same namespace. It may be a backup of another
+	// GameServer, to clone its world.
+	Backup string `json:"backup"`
+	// RequestedAt restores the same backup again when set to a later time.
+	RequestedAt *metav1.Time `json:"requestedAt,omitempty"`
+}
+
+// RestoreResult is the outcome of a restore.
+type RestoreResult string
+
+const (
+	RestoreInProgress RestoreResult = "InProgress"
+	RestoreSucceeded  RestoreResult = "Succeeded"
+	RestoreFailed     RestoreResult = "Failed"
+)
+
+// RestoreStatus records the most recent restore of a GameServer.
+type RestoreStatus struct {
+	Backup      string        `json:"backup"`
+	RequestedAt *metav1.Time  `json:"requestedAt,omitempty"`
+	CompletedAt *metav1.Time  `json:"completedAt,omitempty"`
+	Result      RestoreResult `json:"result,omitempty"`
+	Message     string        `json:"message,omitempty"`
+}
+
+// GameServerState is the lifecycle phase of a GameServer, derived from its Pod and storage.
+// +kubebuilder:validation:Enum=Pending;Provisioning;Starting;Ready;Stopping;Stopped;Failed;CrashLooping
+type GameServerState string
+
+const (
+	// GameServerPending means the Pod doesn't exist yet or can't be scheduled.
+	GameServerPending GameServerState = "Pending"
+	// GameServerProvisioning means the Pod is waiting on its PersistentVolumeClaim to be bound.
+	GameServerProvisioning GameServerState = "Provisioning"
+	// GameServerStarting means the Pod is scheduled but the game container isn't ready yet.
+	GameServerStarting GameServerState = "Starting"
+	// GameServerReady means the game container is running and ready.
+	GameServerReady GameServerState = "Ready"
+	// GameServerStopping means a graceful stop is in progress.
+	GameServerStopping GameServerState = "Stopping"
+	// GameServerStopped means the game exited on its own.
+	GameServerStopped GameServerState = "Stopped"
+	// GameServerFailed means the Pod failed or its container can't be created.
+	GameServerFailed GameServerState = "Failed"
+	// GameServerCrashLooping means the game container keeps exiting and is being restarted with back-off.
+	GameServerCrashLooping GameServerState = "CrashLooping"
+)
+
+// Condition types reported on GameServerStatus.
+const (
+	// ConditionPodScheduled reports whether the Pod was scheduled onto a node.
+	ConditionPodScheduled = "PodScheduled"
+	// ConditionStorageBound reports whether the game data PersistentVolumeClaim is bound.
+	ConditionStorageBound = "StorageBound"
+	// ConditionReady reports whether the game server is ready for players.
+	ConditionReady = "Ready"
+	// ConditionUpToDate reports whether the running Pod matches the current GameServer and GameDefinition spec.
+	ConditionUpToDate = "UpToDate"
+	// ConditionPortsAllocated reports whether every HostPort port got a host port from the operator's range.
+	ConditionPortsAllocated = "PortsAllocated"
+	// ConditionStorageResizing reports whether an expansion of the game data PersistentVolumeClaim is pending.
+	ConditionStorageResizing = "StorageResizing"
+	// ConditionScheduledBackup reports whether the most recent scheduled backup was taken on time and completed.
+	ConditionScheduledBackup = "ScheduledBackup"
+	// ConditionModsSynced reports whether the mods were synced into the game data by the Pod's mods container.
+	ConditionModsSynced = "ModsSynced"
+)
+
+// BackupScheduleStatus reports the scheduled backups of a GameServer.
+type BackupScheduleStatus struct {
+	// Schedule is the cron schedule the times below were computed for.
+	Schedule string `json:"schedule"`
+	// LastScheduleTime is the most recent time a backup was due, whether it was taken or missed.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// NextScheduleTime is when the next backup is due.
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+	// LastBackup is the name of the most recent scheduled GameServerBackup.
+	LastBackup string `json:"lastBackup,omitempty"`
+	// LastSuccessfulTime is when the most recent successful scheduled backup completed.
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+}
+
+// HostPortStatus is the host port allocated to one of the GameServer's HostPort ports.
+type HostPortStatus struct {
+	// Name is the name of the GamePort.
+	Name     string `json:"name"`
+	HostPort int32  `json:"hostPort"`
+}
+
+// GameServicePortStatus is a port of a Service exposing game ports.
+type GameServicePortStatus struct {
+	// Name is the name of the GamePort.
+	Name     string          `json:"name"`
+	Protocol corev1.Protocol `json:"protocol"`
+	Port     int32           `json:"port"`
+	// NodePort is the port opened on every node for NodePort and LoadBalancer Services.
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+// GameServiceStatus describes a Service exposing game ports.
+type GameServiceStatus struct {
+	Name string             `json:"name"`
+	Type corev1.ServiceType `json:"type"`
+	// ExternalAddress is the IP or hostname assigned to a LoadBalancer Service, empty until it is provisioned.
+	ExternalAddress string                  `json:"externalAddress,omitempty"`
+	Ports           []GameServicePortStatus `json:"ports,omitempty"`
+}
+
+// GameServerEndpoint is where players connect to one of the game's ports.
+type GameServerEndpoint struct {
+	// Name is the name of the GamePort.
+	Name     string          `json:"name"`
+	Type     string          `json:"type,omitempty"`
+	Protocol corev1.Protocol `json:"protocol"`
+	// Address is the node's external IP for HostPort and NodePort ports, the LoadBalancer IP or hostname, or
+	// the Service DNS name for ClusterIP ports. It is empty until the Pod is scheduled or the load balancer
+	// is provisioned.
+	Address string `json:"address,omitempty"`
+	Port    int32  `json:"port,omitempty"`
 }
 
 // GameServerStatus defines the observed state of GameServer
 type GameServerStatus struct {
-	State   string `json:"state,omitempty"`
-	Message string `json:"message,omitempty"`
+	State   GameServerState `json:"state,omitempty"`
+	Message string          `json:"message,omitempty"`
+	// ObservedGeneration is the most recent generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// LastRestart describes the most recent restart requested for this GameServer.
+	LastRestart *RestartStatus `json:"lastRestart,omitempty"`
+	// LastRestore describes the most recent restore from spec.restoreFrom.
+	LastRestore *RestoreStatus `json:"lastRestore,omitempty"`
+	// HostPorts are the host ports allocated to the GameServer. They are kept for its whole lifetime,
+	// so the address players saved stays valid when the Pod is recreated.
+	// +listType=map
+	// +listMapKey=name
+	HostPorts []HostPortStatus `json:"hostPorts,omitempty"`
+	// Services are the Services exposing the game's NodePort, ClusterIP and LoadBalancer ports.
+	// +listType=map
+	// +listMapKey=name
+	Services []GameServiceStatus `json:"services,omitempty"`
+	// Endpoints lists where to connect to each of the game's ports.
+	// +listType=map
+	// +listMapKey=name
+	Endpoints []GameServerEndpoint `json:"endpoints,omitempty"`
+	// BackupSchedule reports the scheduled backups, if the GameServer has a backup schedule.
+	BackupSchedule *BackupScheduleStatus `json:"backupSchedule,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Game",type=string,JSONPath=`.spec.game`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.endpoints[0].address`
+// +kubebuilder:printcolumn:name="Port",type=integer,JSONPath=`.status.endpoints[0].port`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // GameServer is the Schema for the gameservers API
 type GameServer struct {
@@ -0,0 +1,116 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GameServerBackupSpec defines the desired state of GameServerBackup
+type GameServerBackupSpec struct {
+	// GameServer is the name of the GameServer to back up, in the same namespace.
+	GameServer string `json:"gameServer"`
+	// SaveCommand is sent to the game's stdin before the data is copied, e.g. "save-all flush". It overrides
+	// the backupStrategy of the GameDefinition.
+	SaveCommand string `json:"saveCommand,omitempty"`
+	// Target is where the backup is stored. Exactly one of its fields must be set.
+	Target BackupTarget `json:"target"`
+}
+
+// BackupTarget is where a GameServerBackup is stored.
+type BackupTarget struct {
+	// PVC writes the backup as a tar.gz archive to a PersistentVolumeClaim in the same namespace.
+	PVC *PVCBackupTarget `json:"pvc,omitempty"`
+	// VolumeSnapshot takes a CSI VolumeSnapshot of the game data claim.
+	VolumeSnapshot *VolumeSnapshotBackupTarget `json:"volumeSnapshot,omitempty"`
+	// S3 uploads the backup as a tar.gz archive to an S3-compatible bucket, e.g. MinIO.
+	S3 *S3BackupTarget `json:"s3,omitempty"`
+}
+
+// PVCBackupTarget stores backups on a PersistentVolumeClaim, under <path>/<namespace>/<kraftnetes-id>/<backup>.tar.gz.
+type PVCBackupTarget struct {
+	ClaimName string `json:"claimName"`
+	Path      string `json:"path,omitempty"`
+}
+
+// VolumeSnapshotBackupTarget stores backups as VolumeSnapshots of the game data claim.
+type VolumeSnapshotBackupTarget struct {
+	// ClassName is the VolumeSnapshotClass. The cluster's default class is used if empty.
+	ClassName string `json:"className,omitempty"`
+}
+
+// S3BackupTarget stores backups in a bucket, under <prefix>/<namespace>/<kraftnetes-id>/<backup>.tar.gz.
+type S3BackupTarget struct {
+	// Endpoint is the URL of the S3 API, e.g. https://s3.eu-central-1.amazonaws.com or http://minio.minio:9000.
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`
+	// CredentialsSecret is a Secret in the same namespace with the keys AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	CredentialsSecret string `json:"credentialsSecret"`
+	// Insecure skips TLS certificate verification.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// GameServerBackupPhase is the progress of a GameServerBackup.
+// +kubebuilder:validation:Enum=Pending;Flushing;Running;Completed;Failed
+type GameServerBackupPhase string
+
+const (
+	// BackupPending means the backup hasn't started yet.
+	BackupPending GameServerBackupPhase = "Pending"
+	// BackupFlushing means the game was asked to save and the operator waits for it to finish writing.
+	BackupFlushing GameServerBackupPhase = "Flushing"
+	// BackupRunning means the data is being copied to the target.
+	BackupRunning GameServerBackupPhase = "Running"
+	// BackupCompleted means the backup is stored in the target.
+	BackupCompleted GameServerBackupPhase = "Completed"
+	// BackupFailed means the backup can't be taken, see the message.
+	BackupFailed GameServerBackupPhase = "Failed"
+)
+
+// GameServerBackupStatus defines the observed state of GameServerBackup
+type GameServerBackupStatus struct {
+	Phase   GameServerBackupPhase `json:"phase,omitempty"`
+	Message string                `json:"message,omitempty"`
+	// GameServerID is the kraftnetes-id of the GameServer, so the backup can be found after it is deleted.
+	GameServerID string `json:"gameServerID,omitempty"`
+	// Location is where the backup is stored: pvc://<claim>/<file>, s3://<bucket>/<key> or the name of the
+	// VolumeSnapshot.
+	Location string `json:"location,omitempty"`
+	// SizeBytes is the size of the archive, or the restore size of the VolumeSnapshot.
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+	// Checksum is the sha256 of the archive as sha256:<hex>. VolumeSnapshots have none.
+	Checksum string `json:"checksum,omitempty"`
+	// FlushedAt is when the game was asked to save.
+	FlushedAt      *metav1.Time `json:"flushedAt,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="GameServer",type=string,JSONPath=`.spec.gameServer`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.sizeBytes`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// GameServerBackup is the Schema for the gameserverbackups API
+type GameServerBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GameServerBackupSpec   `json:"spec,omitempty"`
+	Status GameServerBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GameServerBackupList contains a list of GameServerBackup
+type GameServerBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameServerBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GameServerBackup{}, &GameServerBackupList{})
+}
@@ -19,7 +19,9 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -37,6 +39,7 @@ import (
 
 	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
 	"github.com/Kraftnetes/k8s-operator/internal/controller"
+	webhookkraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -58,6 +61,10 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var hostPortRange string
+	var fileBrowserRoute kraftnetescomv1alpha1.FileBrowserRouteConfig
+	var fileBrowserRouteKind, fileBrowserGateway string
+	var backupImage, backupS3Image, seedImage string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -69,6 +76,25 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&hostPortRange, "host-port-range", controller.DefaultHostPortRange,
+		"The range host ports of HostPort game ports are allocated from, in the form <min>-<max>.")
+	flag.StringVar(&fileBrowserRouteKind, "filebrowser-route", string(kraftnetescomv1alpha1.FileBrowserRouteNone),
+		"How filebrowser is exposed at /files/<id> unless the GameDefinition says otherwise: None, Ingress or HTTPRoute.")
+	flag.StringVar(&fileBrowserRoute.Host, "filebrowser-host", "", "The host name filebrowser is served on.")
+	flag.StringVar(&fileBrowserRoute.IngressClassName, "filebrowser-ingress-class", "",
+		"The IngressClass of filebrowser Ingresses.")
+	flag.StringVar(&fileBrowserRoute.TLSSecretName, "filebrowser-tls-secret", "",
+		"The Secret with the TLS certificate of filebrowser Ingresses.")
+	flag.StringVar(&fileBrowserRoute.ClusterIssuer, "filebrowser-cluster-issuer", "",
+		"The cert-manager ClusterIssuer that issues the certificate of filebrowser Ingresses.")
+	flag.StringVar(&fileBrowserGateway, "filebrowser-gateway", "",
+		"The Gateway filebrowser HTTPRoutes attach to, as <namespace>/<name> or <name>.")
+	flag.StringVar(&backupImage, "backup-image", controller.DefaultBackupImage,
+		"The image archiving and restoring game data for GameServerBackups. It needs sh, tar, gzip and sha256sum.")
+	flag.StringVar(&backupS3Image, "backup-s3-image", controller.DefaultBackupS3Image,
+		"The MinIO client image uploading GameServerBackups to S3 and downloading them for restores.")
+	flag.StringVar(&seedImage, "seed-image", controller.DefaultSeedImage,
+		"The image seeding new game data and syncing mods. It needs sh, wget with TLS support, tar, gzip, unzip and sha256sum.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -77,6 +103,22 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	fileBrowserRoute.Kind = kraftnetescomv1alpha1.FileBrowserRouteKind(fileBrowserRouteKind)
+	switch fileBrowserRoute.Kind {
+	case kraftnetescomv1alpha1.FileBrowserRouteNone, kraftnetescomv1alpha1.FileBrowserRouteIngress, kraftnetescomv1alpha1.FileBrowserRouteHTTPRoute:
+	default:
+		setupLog.Error(fmt.Errorf("unknown kind %q, expected None, Ingress or HTTPRoute", fileBrowserRouteKind),
+			"invalid --filebrowser-route")
+		os.Exit(1)
+	}
+	if fileBrowserGateway != "" {
+		namespace, name, found := strings.Cut(fileBrowserGateway, "/")
+		if !found {
+			namespace, name = "", fileBrowserGateway
+		}
+		fileBrowserRoute.Gateway = &kraftnetescomv1alpha1.GatewayReference{Name: name, Namespace: namespace}
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -144,9 +186,31 @@ func main() {
 		os.Exit(1)
 	}
 
+	podExecutor, err := controller.NewPodExecutor(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create pod executor")
+		os.Exit(1)
+	}
+	firstHostPort, lastHostPort, err := controller.ParseHostPortRange(hostPortRange)
+	if err != nil {
+		setupLog.Error(err, "invalid --host-port-range")
+		os.Exit(1)
+	}
+	hostPorts, err := controller.NewHostPortAllocator(firstHostPort, lastHostPort)
+	if err != nil {
+		setupLog.Error(err, "invalid --host-port-range")
+		os.Exit(1)
+	}
 	if err = (&controller.GameServerReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Executor:  podExecutor,
+		HostPorts: hostPorts,
+
+		FileBrowserRoute: fileBrowserRoute,
+		BackupImage:      backupImage,
+		BackupS3Image:    backupS3Image,
+		SeedImage:        seedImage,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GameServer")
 		os.Exit(1)
@@ -158,6 +222,27 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "GameDefinition")
 		os.Exit(1)
 	}
+	if err = (&controller.GameServerBackupReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Executor: podExecutor,
+		Image:    backupImage,
+		S3Image:  backupS3Image,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GameServerBackup")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookkraftnetescomv1alpha1.SetupGameServerWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GameServer")
+			os.Exit(1)
+		}
+		if err = webhookkraftnetescomv1alpha1.SetupGameDefinitionWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GameDefinition")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
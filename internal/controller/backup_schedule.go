@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// scheduledBackupLabel marks the GameServerBackups created by a backup schedule. Only those are pruned.
+	scheduledBackupLabel = "kraftnetes.com/scheduled-backup"
+	// scheduledAtAnnotation is the schedule time a backup was taken for, retention is based on it.
+	scheduledAtAnnotation = "kraftnetes.com/scheduled-at"
+
+	// backupStartingDeadline is how late a scheduled backup may still start, e.g. after the operator was down.
+	backupStartingDeadline = time.Hour
+	// maxMissedSchedules bounds the schedule times counted as missed, a frequent schedule could otherwise
+	// take long to catch up on after a long downtime.
+	maxMissedSchedules = 100
+	// defaultKeepLast is the number of backups kept if the retention sets no rule.
+	defaultKeepLast = 7
+)
+
+// backupSchedule returns the GameServer's backup schedule, else the one of its profile's or GameDefinition's
+// storage, or nil if it has none.
+func backupSchedule(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) *v1alpha1.BackupSchedule {
+	if gs.Spec.Backup != nil {
+		return gs.Spec.Backup
+	}
+	if storage := storageConfig(gs, gameDef); storage != nil {
+		return storage.Backup
+	}
+	return nil
+}
+
+// scheduledBackupTarget returns the target of the scheduled backups, a VolumeSnapshot if none is set.
+func scheduledBackupTarget(schedule *v1alpha1.BackupSchedule) v1alpha1.BackupTarget {
+	target := schedule.Target
+	if target.PVC == nil && target.VolumeSnapshot == nil && target.S3 == nil {
+		target.VolumeSnapshot = &v1alpha1.VolumeSnapshotBackupTarget{}
+	}
+	return target
+}
+
+// reconcileBackupSchedule creates a GameServerBackup whenever the schedule is due, reports the outcome of the
+// latest one in the ScheduledBackup condition and prunes the backups the retention doesn't keep. Scheduled
+// backups aren't owned by the GameServer, they outlive it like retained claims.
+func (r *GameServerReconciler) reconcileBackupSchedule(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	schedule := backupSchedule(gs, gameDef)
+	if schedule == nil || !usesStorage(gs, gameDef) {
+		if gs.Status.BackupSchedule == nil && meta.FindStatusCondition(gs.Status.Conditions, v1alpha1.ConditionScheduledBackup) == nil {
+			return ctrl.Result{}, nil
+		}
+		gs.Status.BackupSchedule = nil
+		meta.RemoveStatusCondition(&gs.Status.Conditions, v1alpha1.ConditionScheduledBackup)
+		if err := r.Status().Update(ctx, gs); err != nil {
+			logger.Error(err, "Failed to update GameServer status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	cron, err := parseCronSchedule(schedule.Schedule)
+	if err != nil {
+		// ValidateGameServer rejects invalid schedules before the subreconcilers run.
+		return ctrl.Result{}, fmt.Errorf("invalid backup schedule %q: %w", schedule.Schedule, err)
+	}
+
+	now := time.Now().UTC()
+	original := gs.Status.DeepCopy()
+	status := gs.Status.BackupSchedule
+	if status == nil {
+		status = &v1alpha1.BackupScheduleStatus{}
+		gs.Status.BackupSchedule = status
+	}
+	if status.Schedule != schedule.Schedule || status.NextScheduleTime == nil || status.NextScheduleTime.IsZero() {
+		// A new or changed schedule starts from now, earlier times of it were never due.
+		status.Schedule = schedule.Schedule
+		status.NextScheduleTime = &metav1.Time{Time: cron.next(now)}
+	}
+	if status.NextScheduleTime.IsZero() {
+		// A schedule without a next time would count as always due.
+		return ctrl.Result{}, fmt.Errorf("backup schedule %q never matches", schedule.Schedule)
+	}
+
+	backups, err := r.listScheduledBackups(ctx, gs)
+	if err != nil {
+		logger.Error(err, "Failed to list scheduled GameServerBackups")
+		return ctrl.Result{}, err
+	}
+
+	if !now.Before(status.NextScheduleTime.Time) {
+		due, missed := dueScheduleTime(cron, status.NextScheduleTime.Time, now)
+		status.LastScheduleTime = &metav1.Time{Time: due}
+		status.NextScheduleTime = &metav1.Time{Time: cron.next(due)}
+		if missed > 0 {
+			r.Recorder.Eventf(gs, corev1.EventTypeWarning, "MissedSchedule", "Missed %d scheduled backups before %s", missed, due.Format(time.RFC3339))
+		}
+
+		active := activeBackup(backups)
+		switch {
+		case now.Sub(due) > backupStartingDeadline:
+			r.missSchedule(gs, fmt.Sprintf("The backup due at %s couldn't start within %s", due.Format(time.RFC3339), backupStartingDeadline))
+		case active != nil:
+			r.missSchedule(gs, fmt.Sprintf("The backup due at %s was skipped, backup %s is still %s", due.Format(time.RFC3339), active.Name, phaseOrPending(active)))
+		default:
+			backup := buildScheduledBackup(gs, schedule, due)
+			if err := r.Create(ctx, backup); err != nil && !apierrors.IsAlreadyExists(err) {
+				logger.Error(err, "Failed to create scheduled GameServerBackup")
+				r.Recorder.Event(gs, corev1.EventTypeWarning, "BackupCreateFailed", err.Error())
+				return ctrl.Result{}, err
+			}
+			status.LastBackup = backup.Name
+			backups = append(backups, *backup)
+			r.Recorder.Eventf(gs, corev1.EventTypeNormal, "ScheduledBackupCreated", "Created GameServerBackup %s", backup.Name)
+		}
+	}
+
+	// Only the backup taken for the latest schedule time decides the condition, a missed schedule sticks
+	// until the next backup.
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Name != status.LastBackup || status.LastScheduleTime == nil || !backupScheduledAt(backup).Equal(status.LastScheduleTime.Time) {
+			continue
+		}
+		switch backup.Status.Phase {
+		case v1alpha1.BackupCompleted:
+			status.LastSuccessfulTime = backup.Status.CompletionTime
+			meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionScheduledBackup,
+				Status:             metav1.ConditionTrue,
+				Reason:             "BackupCompleted",
+				Message:            fmt.Sprintf("Backup %s completed", backup.Name),
+				ObservedGeneration: gs.Generation,
+			})
+		case v1alpha1.BackupFailed:
+			if meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionScheduledBackup,
+				Status:             metav1.ConditionFalse,
+				Reason:             "BackupFailed",
+				Message:            fmt.Sprintf("Backup %s failed: %s", backup.Name, backup.Status.Message),
+				ObservedGeneration: gs.Generation,
+			}) {
+				r.Recorder.Eventf(gs, corev1.EventTypeWarning, "ScheduledBackupFailed", "Backup %s failed: %s", backup.Name, backup.Status.Message)
+			}
+		}
+	}
+
+	if pruned := backupsToPrune(backups, schedule.Retention); len(pruned) > 0 {
+		names := make([]string, 0, len(pruned))
+		for i := range pruned {
+			if err := r.Delete(ctx, &pruned[i]); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "Failed to prune GameServerBackup", "backup", pruned[i].Name)
+				return ctrl.Result{}, err
+			}
+			names = append(names, pruned[i].Name)
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "BackupsPruned", "Pruned backups %s", strings.Join(names, ", "))
+	}
+
+	if !reflect.DeepEqual(*original, gs.Status) {
+		if err := r.Status().Update(ctx, gs); err != nil {
+			logger.Error(err, "Failed to update GameServer status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: status.NextScheduleTime.Sub(now)}, nil
+}
+
+// missSchedule reports a scheduled backup that wasn't taken.
+func (r *GameServerReconciler) missSchedule(gs *v1alpha1.GameServer, message string) {
+	meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionScheduledBackup,
+		Status:             metav1.ConditionFalse,
+		Reason:             "MissedSchedule",
+		Message:            message,
+		ObservedGeneration: gs.Generation,
+	})
+	r.Recorder.Event(gs, corev1.EventTypeWarning, "MissedSchedule", message)
+}
+
+// dueScheduleTime returns the latest schedule time from next up to now, and how many earlier ones were missed,
+// at most maxMissedSchedules.
+func dueScheduleTime(cron *cronSchedule, next, now time.Time) (time.Time, int) {
+	due, missed := next, 0
+	for t := cron.next(next); !t.IsZero() && !t.After(now); t = cron.next(t) {
+		due = t
+		if missed < maxMissedSchedules {
+			missed++
+		} else if catchUp := now.Add(-backupStartingDeadline); t.Before(catchUp) {
+			// Stop counting and skip ahead, times before the starting deadline would be missed anyway.
+			t = catchUp
+		}
+	}
+	return due, missed
+}
+
+// listScheduledBackups returns the scheduled backups of the GameServer's kraftnetes-id, including those taken
+// before it was recreated on a retained claim.
+func (r *GameServerReconciler) listScheduledBackups(ctx context.Context, gs *v1alpha1.GameServer) ([]v1alpha1.GameServerBackup, error) {
+	backups := &v1alpha1.GameServerBackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(gs.Namespace), client.MatchingLabels{
+		scheduledBackupLabel: "true",
+		gameServerIdLabel:    ResolveGameServerId(gs),
+	}); err != nil {
+		return nil, err
+	}
+	return backups.Items, nil
+}
+
+// buildScheduledBackup returns the GameServerBackup for the schedule time scheduledAt. Archives of scheduled
+// backups are deleted together with the backup, so pruning frees the space.
+func buildScheduledBackup(gs *v1alpha1.GameServer, schedule *v1alpha1.BackupSchedule, scheduledAt time.Time) *v1alpha1.GameServerBackup {
+	backup := &v1alpha1.GameServerBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", gs.Name, scheduledAt.UTC().Format("20060102-1504")),
+			Namespace: gs.Namespace,
+			Labels: map[string]string{
+				"gameserver":         gs.Name,
+				gameServerIdLabel:    ResolveGameServerId(gs),
+				scheduledBackupLabel: "true",
+			},
+			Annotations: map[string]string{scheduledAtAnnotation: scheduledAt.UTC().Format(time.RFC3339)},
+		},
+		Spec: v1alpha1.GameServerBackupSpec{
+			GameServer:  gs.Name,
+			SaveCommand: schedule.SaveCommand,
+			Target:      scheduledBackupTarget(schedule),
+		},
+	}
+	if backup.Spec.Target.VolumeSnapshot == nil {
+		backup.Finalizers = []string{backupArchiveFinalizer}
+	}
+	return backup
+}
+
+// backupScheduledAt returns the schedule time of a scheduled backup, its creation time if it has none.
+func backupScheduledAt(backup *v1alpha1.GameServerBackup) time.Time {
+	if t, err := time.Parse(time.RFC3339, backup.Annotations[scheduledAtAnnotation]); err == nil {
+		return t.UTC()
+	}
+	return backup.CreationTimestamp.UTC()
+}
+
+// activeBackup returns a backup that hasn't finished yet, or nil.
+func activeBackup(backups []v1alpha1.GameServerBackup) *v1alpha1.GameServerBackup {
+	for i := range backups {
+		if backups[i].DeletionTimestamp.IsZero() && !backupFinished(&backups[i]) {
+			return &backups[i]
+		}
+	}
+	return nil
+}
+
+func backupFinished(backup *v1alpha1.GameServerBackup) bool {
+	return backup.Status.Phase == v1alpha1.BackupCompleted || backup.Status.Phase == v1alpha1.BackupFailed
+}
+
+func phaseOrPending(backup *v1alpha1.GameServerBackup) v1alpha1.GameServerBackupPhase {
+	if backup.Status.Phase == "" {
+		return v1alpha1.BackupPending
+	}
+	return backup.Status.Phase
+}
+
+// backupsToPrune returns the finished backups the retention doesn't keep. The rules only look at completed
+// backups. Failed backups are pruned once a newer backup completed, unfinished ones are never pruned.
+func backupsToPrune(backups []v1alpha1.GameServerBackup, retention v1alpha1.BackupRetention) []v1alpha1.GameServerBackup {
+	if retention.KeepLast == 0 && retention.KeepDaily == 0 && retention.KeepWeekly == 0 && retention.KeepMonthly == 0 {
+		retention.KeepLast = defaultKeepLast
+	}
+
+	sorted := make([]v1alpha1.GameServerBackup, 0, len(backups))
+	for _, backup := range backups {
+		if backup.DeletionTimestamp.IsZero() && backupFinished(&backup) {
+			sorted = append(sorted, backup)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return backupScheduledAt(&sorted[i]).After(backupScheduledAt(&sorted[j]))
+	})
+
+	var completed []int
+	for i := range sorted {
+		if sorted[i].Status.Phase == v1alpha1.BackupCompleted {
+			completed = append(completed, i)
+		}
+	}
+	keep := map[int]bool{}
+	for n, i := range completed {
+		if n < int(retention.KeepLast) {
+			keep[i] = true
+		}
+	}
+	keepGenerations := func(count int32, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for _, i := range completed {
+			if len(seen) >= int(count) {
+				return
+			}
+			if p := period(backupScheduledAt(&sorted[i])); !seen[p] {
+				seen[p] = true
+				keep[i] = true
+			}
+		}
+	}
+	keepGenerations(retention.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keepGenerations(retention.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepGenerations(retention.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") })
+
+	var pruned []v1alpha1.GameServerBackup
+	for i := range sorted {
+		if keep[i] {
+			continue
+		}
+		if sorted[i].Status.Phase == v1alpha1.BackupFailed && (len(completed) == 0 || completed[0] > i) {
+			// No newer backup completed yet, keep the failure around to look into.
+			continue
+		}
+		pruned = append(pruned, sorted[i])
+	}
+	return pruned
+}
+
+// gameServerForScheduledBackup maps a scheduled backup to a reconcile request for its GameServer, so the
+// ScheduledBackup condition follows the backup's progress.
+func (r *GameServerReconciler) gameServerForScheduledBackup(ctx context.Context, obj client.Object) []reconcile.Request {
+	backup, ok := obj.(*v1alpha1.GameServerBackup)
+	if !ok || backup.Labels[scheduledBackupLabel] != "true" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: backup.Spec.GameServer, Namespace: backup.Namespace}}}
+}
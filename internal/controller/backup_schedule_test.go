@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Backup schedule", func() {
+	at := func(value string) time.Time {
+		t, err := time.Parse(time.RFC3339, value)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+
+	Context("cron", func() {
+		next := func(spec, from string) string {
+			schedule, err := parseCronSchedule(spec)
+			Expect(err).NotTo(HaveOccurred())
+			return schedule.next(at(from)).Format(time.RFC3339)
+		}
+
+		It("finds the next matching minute", func() {
+			Expect(next("*/15 * * * *", "2024-03-10T10:07:30Z")).To(Equal("2024-03-10T10:15:00Z"))
+			Expect(next("0 4 * * *", "2024-03-10T04:00:00Z")).To(Equal("2024-03-11T04:00:00Z"))
+			Expect(next("@monthly", "2024-12-15T00:00:00Z")).To(Equal("2025-01-01T00:00:00Z"))
+			Expect(next("30 2 * * mon-fri", "2024-03-08T03:00:00Z")).To(Equal("2024-03-11T02:30:00Z"))
+			Expect(next("0 0 29 feb *", "2024-03-01T00:00:00Z")).To(Equal("2028-02-29T00:00:00Z"))
+		})
+
+		It("matches either restricted day field", func() {
+			// The 13th or any Friday.
+			Expect(next("0 0 13 * 5", "2024-03-01T00:00:00Z")).To(Equal("2024-03-08T00:00:00Z"))
+			Expect(next("0 0 13 * 5", "2024-03-08T00:00:00Z")).To(Equal("2024-03-13T00:00:00Z"))
+			Expect(next("0 0 * * 7", "2024-03-01T00:00:00Z")).To(Equal("2024-03-03T00:00:00Z"))
+		})
+
+		It("rejects invalid expressions", func() {
+			for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "0 0 30 2 *"} {
+				_, err := parseCronSchedule(spec)
+				Expect(err).To(HaveOccurred(), spec)
+			}
+		})
+
+		It("counts the schedule times missed before the latest one", func() {
+			schedule, err := parseCronSchedule("0 * * * *")
+			Expect(err).NotTo(HaveOccurred())
+			due, missed := dueScheduleTime(schedule, at("2024-03-10T01:00:00Z"), at("2024-03-10T04:30:00Z"))
+			Expect(due).To(Equal(at("2024-03-10T04:00:00Z")))
+			Expect(missed).To(Equal(3))
+
+			schedule, err = parseCronSchedule("* * * * *")
+			Expect(err).NotTo(HaveOccurred())
+			due, missed = dueScheduleTime(schedule, at("2024-03-01T00:00:00Z"), at("2024-03-10T04:30:00Z"))
+			Expect(due).To(Equal(at("2024-03-10T04:30:00Z")))
+			Expect(missed).To(Equal(maxMissedSchedules))
+		})
+	})
+
+	Context("retention", func() {
+		backup := func(day time.Time, phase kraftnetescomv1alpha1.GameServerBackupPhase) kraftnetescomv1alpha1.GameServerBackup {
+			return kraftnetescomv1alpha1.GameServerBackup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        day.Format("0102"),
+					Annotations: map[string]string{scheduledAtAnnotation: day.Format(time.RFC3339)},
+				},
+				Status: kraftnetescomv1alpha1.GameServerBackupStatus{Phase: phase},
+			}
+		}
+		names := func(backups []kraftnetescomv1alpha1.GameServerBackup) []string {
+			var out []string
+			for _, b := range backups {
+				out = append(out, b.Name)
+			}
+			return out
+		}
+
+		It("keeps the last backups plus daily, weekly and monthly generations", func() {
+			var backups []kraftnetescomv1alpha1.GameServerBackup
+			for day := at("2024-01-01T04:00:00Z"); !day.After(at("2024-02-09T04:00:00Z")); day = day.AddDate(0, 0, 1) {
+				backups = append(backups, backup(day, kraftnetescomv1alpha1.BackupCompleted))
+			}
+			pruned := names(backupsToPrune(backups, kraftnetescomv1alpha1.BackupRetention{
+				KeepLast: 3, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 2,
+			}))
+			Expect(pruned).To(HaveLen(len(backups) - 10))
+			// The last 7 days, the newest of the weeks before and the newest of January.
+			for _, kept := range []string{"0209", "0203", "0128", "0121", "0131"} {
+				Expect(pruned).NotTo(ContainElement(kept))
+			}
+			Expect(pruned).To(ContainElements("0202", "0129", "0120", "0101"))
+		})
+
+		It("keeps the last 7 backups without rules and never prunes unfinished ones", func() {
+			var backups []kraftnetescomv1alpha1.GameServerBackup
+			for day := at("2024-01-01T04:00:00Z"); !day.After(at("2024-01-10T04:00:00Z")); day = day.AddDate(0, 0, 1) {
+				backups = append(backups, backup(day, kraftnetescomv1alpha1.BackupCompleted))
+			}
+			backups = append(backups, backup(at("2024-01-11T04:00:00Z"), kraftnetescomv1alpha1.BackupRunning))
+			Expect(names(backupsToPrune(backups, kraftnetescomv1alpha1.BackupRetention{}))).To(ConsistOf("0101", "0102", "0103"))
+		})
+
+		It("prunes failed backups once a newer backup completed", func() {
+			backups := []kraftnetescomv1alpha1.GameServerBackup{
+				backup(at("2024-01-01T04:00:00Z"), kraftnetescomv1alpha1.BackupFailed),
+				backup(at("2024-01-02T04:00:00Z"), kraftnetescomv1alpha1.BackupCompleted),
+				backup(at("2024-01-03T04:00:00Z"), kraftnetescomv1alpha1.BackupFailed),
+			}
+			Expect(names(backupsToPrune(backups, kraftnetescomv1alpha1.BackupRetention{KeepLast: 1}))).To(ConsistOf("0101"))
+		})
+	})
+
+	Context("reconciling", func() {
+		var (
+			ctx        = context.Background()
+			reconciler *GameServerReconciler
+			gs         *kraftnetescomv1alpha1.GameServer
+			gameDef    *kraftnetescomv1alpha1.GameDefinition
+			due        time.Time
+		)
+
+		BeforeEach(func() {
+			due = time.Now().UTC().Truncate(time.Minute).Add(-10 * time.Minute)
+			gs = &kraftnetescomv1alpha1.GameServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "survival",
+					Namespace: "default",
+					Labels:    map[string]string{"kraftnetes-id": "abc123"},
+				},
+				Status: kraftnetescomv1alpha1.GameServerStatus{BackupSchedule: &kraftnetescomv1alpha1.BackupScheduleStatus{
+					Schedule:         "@yearly",
+					NextScheduleTime: &metav1.Time{Time: due},
+				}},
+			}
+			gameDef = &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Storage: &kraftnetescomv1alpha1.StorageConfig{
+					Enabled: kraftnetescomv1alpha1.FromBool(true),
+					Backup:  &kraftnetescomv1alpha1.BackupSchedule{Schedule: "@yearly", SaveCommand: "save-all flush"},
+				},
+			}}
+		})
+
+		setup := func(objects ...client.Object) {
+			reconciler, _ = newFakeGameServerReconciler(append(objects, gs)...)
+		}
+
+		scheduledBackup := func(name string, scheduledAt time.Time, phase kraftnetescomv1alpha1.GameServerBackupPhase) *kraftnetescomv1alpha1.GameServerBackup {
+			backup := buildScheduledBackup(gs, gameDef.Spec.Storage.Backup, scheduledAt)
+			backup.Name = name
+			backup.Status.Phase = phase
+			return backup
+		}
+
+		It("creates a backup when the schedule is due", func() {
+			setup()
+			res, err := reconciler.reconcileBackupSchedule(ctx, gs, gameDef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+
+			name := fmt.Sprintf("survival-%s", due.Format("20060102-1504"))
+			Expect(gs.Status.BackupSchedule.LastBackup).To(Equal(name))
+			Expect(gs.Status.BackupSchedule.LastScheduleTime.Time).To(BeTemporally("==", due))
+			Expect(gs.Status.BackupSchedule.NextScheduleTime.After(time.Now())).To(BeTrue())
+
+			backup := &kraftnetescomv1alpha1.GameServerBackup{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, backup)).To(Succeed())
+			Expect(backup.Spec.SaveCommand).To(Equal("save-all flush"))
+			Expect(backup.Spec.Target.VolumeSnapshot).NotTo(BeNil())
+			Expect(backup.Labels).To(HaveKeyWithValue(scheduledBackupLabel, "true"))
+			Expect(backup.Finalizers).To(BeEmpty())
+		})
+
+		It("refuses a schedule that never matches instead of counting it as due", func() {
+			gameDef.Spec.Storage.Backup.Schedule = "0 0 30 2 *"
+			gs.Status.BackupSchedule = nil
+			var recorder *record.FakeRecorder
+			reconciler, recorder = newFakeGameServerReconciler(gs)
+			_, err := reconciler.reconcileBackupSchedule(ctx, gs, gameDef)
+			Expect(err).To(MatchError(ContainSubstring("never matches")))
+			Expect(recorder.Events).NotTo(Receive())
+
+			backups := &kraftnetescomv1alpha1.GameServerBackupList{}
+			Expect(reconciler.List(ctx, backups)).To(Succeed())
+			Expect(backups.Items).To(BeEmpty())
+		})
+
+		It("reports a failed scheduled backup", func() {
+			gs.Status.BackupSchedule = &kraftnetescomv1alpha1.BackupScheduleStatus{
+				Schedule:         "@yearly",
+				LastScheduleTime: &metav1.Time{Time: due},
+				NextScheduleTime: &metav1.Time{Time: due.AddDate(1, 0, 0)},
+				LastBackup:       "survival-failed",
+			}
+			failed := scheduledBackup("survival-failed", due, kraftnetescomv1alpha1.BackupFailed)
+			failed.Status.Message = "VolumeSnapshot failed"
+			setup(failed)
+
+			_, err := reconciler.reconcileBackupSchedule(ctx, gs, gameDef)
+			Expect(err).NotTo(HaveOccurred())
+			condition := meta.FindStatusCondition(gs.Status.Conditions, kraftnetescomv1alpha1.ConditionScheduledBackup)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("BackupFailed"))
+			Expect(reconciler.Recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("ScheduledBackupFailed")))
+		})
+
+		It("skips the schedule while the previous backup is still running", func() {
+			running := scheduledBackup("survival-running", due.AddDate(-1, 0, 0), kraftnetescomv1alpha1.BackupRunning)
+			setup(running)
+
+			_, err := reconciler.reconcileBackupSchedule(ctx, gs, gameDef)
+			Expect(err).NotTo(HaveOccurred())
+			condition := meta.FindStatusCondition(gs.Status.Conditions, kraftnetescomv1alpha1.ConditionScheduledBackup)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal("MissedSchedule"))
+
+			backups := &kraftnetescomv1alpha1.GameServerBackupList{}
+			Expect(reconciler.List(ctx, backups)).To(Succeed())
+			Expect(backups.Items).To(HaveLen(1))
+		})
+
+		It("misses a schedule that is past its starting deadline", func() {
+			gs.Status.BackupSchedule.NextScheduleTime = &metav1.Time{Time: due.Add(-2 * time.Hour)}
+			setup()
+
+			_, err := reconciler.reconcileBackupSchedule(ctx, gs, gameDef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.IsStatusConditionFalse(gs.Status.Conditions, kraftnetescomv1alpha1.ConditionScheduledBackup)).To(BeTrue())
+			Expect(gs.Status.BackupSchedule.LastBackup).To(BeEmpty())
+		})
+	})
+})
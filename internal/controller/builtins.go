@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// builtinPrefixes are the namespaces of the built-in template variables. Inputs can't use them.
+var builtinPrefixes = []string{"gs.", "ports.", "resources."}
+
+// builtinValues returns the built-in template variables of a GameServer:
+//
+//	${gs.name}, ${gs.id}, ${gs.namespace}
+//	${ports.<name>.hostPort}                       for every HostPort port of the chosen profile
+//	${resources.<limits|requests>.memoryMi}        memory in MiB, if set
+//	${resources.<limits|requests>.cpuMilli}        CPU in millicores, if set
+//
+// Ports and resources are taken after the profile and GameServer overrides are merged, so they match what
+// the Pod gets.
+func builtinValues(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) map[string]interface{} {
+	values := map[string]interface{}{
+		"gs.name":      gs.Name,
+		"gs.id":        ResolveGameServerId(gs),
+		"gs.namespace": gs.Namespace,
+	}
+
+	mergedConfig, _, finalResources := resolveConfigEnvResources(gs, gameDef)
+	// Until reconcileHostPorts allocated them, e.g. during admission, host ports resolve to 0.
+	for _, name := range hostPortNames(mergedConfig.Ports) {
+		values["ports."+name+".hostPort"] = json.Number(strconv.Itoa(int(allocatedHostPort(gs, name))))
+	}
+
+	for kind, list := range map[string]corev1.ResourceList{"limits": finalResources.Limits, "requests": finalResources.Requests} {
+		if memory, ok := list[corev1.ResourceMemory]; ok {
+			values["resources."+kind+".memoryMi"] = json.Number(strconv.FormatInt(memory.Value()/(1024*1024), 10))
+		}
+		if cpu, ok := list[corev1.ResourceCPU]; ok {
+			values["resources."+kind+".cpuMilli"] = json.Number(strconv.FormatInt(cpu.MilliValue(), 10))
+		}
+	}
+	return values
+}
+
+// isBuiltinPlaceholder reports whether name is a built-in variable that can exist for some GameServer of
+// gameDef. Resources depend on the GameServer alone, a missing one is reported when it is resolved.
+func isBuiltinPlaceholder(gameDef *v1alpha1.GameDefinition, name string) bool {
+	switch name {
+	case "gs.name", "gs.id", "gs.namespace",
+		"resources.limits.memoryMi", "resources.limits.cpuMilli",
+		"resources.requests.memoryMi", "resources.requests.cpuMilli":
+		return true
+	}
+
+	portName, ok := strings.CutPrefix(name, "ports.")
+	if !ok {
+		return false
+	}
+	portName, ok = strings.CutSuffix(portName, ".hostPort")
+	if !ok {
+		return false
+	}
+	ports := gameDef.Spec.Ports
+	if gameDef.Spec.Profiles != nil {
+		for _, profile := range gameDef.Spec.Profiles.Values {
+			ports = append(ports[:len(ports):len(ports)], profile.Ports...)
+		}
+	}
+	for _, gp := range ports {
+		if gp.Name == portName && gp.Type == portTypeHostPort {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedInputName reports whether name is in the namespace of the built-in variables.
+func reservedInputName(name string) bool {
+	for _, prefix := range builtinPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gameServerEndpoints returns where players connect to each game port. HostPort and NodePort ports are
+// reached through the node the Pod runs on, so they follow the Pod when it moves; node is nil until it
+// is scheduled. LoadBalancer and ClusterIP ports use the Services recorded in the status, ports without a
+// type the Pod IP.
+func gameServerEndpoints(gs *v1alpha1.GameServer, ports []v1alpha1.GamePort, pod *corev1.Pod, node *corev1.Node) []v1alpha1.GameServerEndpoint {
+	servicePorts := map[string]v1alpha1.GameServicePortStatus{}
+	serviceOf := map[string]v1alpha1.GameServiceStatus{}
+	for _, service := range gs.Status.Services {
+		for _, port := range service.Ports {
+			servicePorts[port.Name] = port
+			serviceOf[port.Name] = service
+		}
+	}
+
+	var endpoints []v1alpha1.GameServerEndpoint
+	for _, gp := range ports {
+		endpoint := v1alpha1.GameServerEndpoint{
+			Name:     gp.Name,
+			Type:     gp.Type,
+			Protocol: corev1.Protocol(gp.Protocol),
+		}
+		if endpoint.Protocol == "" {
+			endpoint.Protocol = corev1.ProtocolTCP
+		}
+
+		switch gp.Type {
+		case portTypeHostPort:
+			endpoint.Address = nodeAddress(pod, node)
+			endpoint.Port = allocatedHostPort(gs, gp.Name)
+		case portTypeNodePort:
+			endpoint.Address = nodeAddress(pod, node)
+			endpoint.Port = servicePorts[gp.Name].NodePort
+		case portTypeLoadBalancer:
+			endpoint.Address = serviceOf[gp.Name].ExternalAddress
+			endpoint.Port = servicePorts[gp.Name].Port
+		case portTypeClusterIP:
+			if service, ok := serviceOf[gp.Name]; ok {
+				endpoint.Address = fmt.Sprintf("%s.%s.svc", service.Name, gs.Namespace)
+			}
+			endpoint.Port = servicePorts[gp.Name].Port
+		default:
+			if pod != nil {
+				endpoint.Address = pod.Status.PodIP
+			}
+			endpoint.Port = gp.ContainerPort.IntVal
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// nodeAddress returns the address players reach the Pod's node on: its external IP or DNS name if it has
+// one, otherwise its internal IP.
+func nodeAddress(pod *corev1.Pod, node *corev1.Node) string {
+	if node != nil {
+		for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeExternalDNS, corev1.NodeInternalIP} {
+			for _, address := range node.Status.Addresses {
+				if address.Type == addressType {
+					return address.Address
+				}
+			}
+		}
+	}
+	if pod != nil {
+		return pod.Status.HostIP
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Endpoints", func() {
+	var (
+		gs    *kraftnetescomv1alpha1.GameServer
+		ports []kraftnetescomv1alpha1.GamePort
+		pod   *corev1.Pod
+		node  *corev1.Node
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "games"},
+			Status: kraftnetescomv1alpha1.GameServerStatus{
+				HostPorts: []kraftnetescomv1alpha1.HostPortStatus{{Name: "java", HostPort: 30123}},
+				Services: []kraftnetescomv1alpha1.GameServiceStatus{
+					{
+						Name: "gs-survival-loadbalancer-udp-service", Type: corev1.ServiceTypeLoadBalancer, ExternalAddress: "192.0.2.10",
+						Ports: []kraftnetescomv1alpha1.GameServicePortStatus{{Name: "bedrock", Protocol: corev1.ProtocolUDP, Port: 19132, NodePort: 31132}},
+					},
+					{
+						Name: "gs-survival-clusterip-service", Type: corev1.ServiceTypeClusterIP,
+						Ports: []kraftnetescomv1alpha1.GameServicePortStatus{{Name: "rcon", Protocol: corev1.ProtocolTCP, Port: 25575}},
+					},
+				},
+			},
+		}
+		ports = []kraftnetescomv1alpha1.GamePort{
+			{Name: "java", ContainerPort: intstr.FromInt(25565), Protocol: "TCP", Type: portTypeHostPort},
+			{Name: "bedrock", ContainerPort: intstr.FromInt(19132), Protocol: "UDP", Type: portTypeLoadBalancer},
+			{Name: "rcon", ContainerPort: intstr.FromInt(25575), Type: portTypeClusterIP},
+		}
+		pod = &corev1.Pod{Status: corev1.PodStatus{HostIP: "10.0.0.5", PodIP: "10.244.1.7"}}
+		node = &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.5"},
+			{Type: corev1.NodeExternalIP, Address: "203.0.113.5"},
+		}}}
+	})
+
+	It("publishes one endpoint per game port", func() {
+		Expect(gameServerEndpoints(gs, ports, pod, node)).To(Equal([]kraftnetescomv1alpha1.GameServerEndpoint{
+			{Name: "java", Type: portTypeHostPort, Protocol: corev1.ProtocolTCP, Address: "203.0.113.5", Port: 30123},
+			{Name: "bedrock", Type: portTypeLoadBalancer, Protocol: corev1.ProtocolUDP, Address: "192.0.2.10", Port: 19132},
+			{Name: "rcon", Type: portTypeClusterIP, Protocol: corev1.ProtocolTCP, Address: "gs-survival-clusterip-service.games.svc", Port: 25575},
+		}))
+	})
+
+	It("falls back to the internal IP of the node", func() {
+		node.Status.Addresses = node.Status.Addresses[:1]
+		Expect(gameServerEndpoints(gs, ports, pod, node)[0].Address).To(Equal("10.0.0.5"))
+	})
+
+	It("leaves the address empty until the Pod is scheduled", func() {
+		endpoints := gameServerEndpoints(gs, ports, nil, nil)
+		Expect(endpoints[0].Address).To(BeEmpty())
+		Expect(endpoints[0].Port).To(Equal(int32(30123)))
+	})
+})
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// defaultFileBrowserImage is used unless the GameDefinition sets fileBrowserConfig.image.
+	defaultFileBrowserImage = "filebrowser/filebrowser:v2.31.2"
+	// fileBrowserAdminUser is the name of the filebrowser user the operator creates.
+	fileBrowserAdminUser = "admin"
+	// fileBrowserDatabaseVolume holds the filebrowser database. It is rebuilt whenever the Pod starts, so the
+	// credentials in the Secret are the only ones that work.
+	fileBrowserDatabaseVolume = "filebrowser-database"
+	fileBrowserDatabasePath   = "/database/filebrowser.db"
+)
+
+// fileBrowserSecretName returns the name of the Secret holding the filebrowser admin credentials.
+func fileBrowserSecretName(gs *v1alpha1.GameServer) string {
+	return fmt.Sprintf("gs-%s-filebrowser", ResolveGameServerId(gs))
+}
+
+// reconcileFileBrowserSecret generates the filebrowser admin credentials once and keeps them in a Secret,
+// with the keys username and password.
+func (r *GameServerReconciler) reconcileFileBrowserSecret(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	if !fileBrowserEnabled(gs, gameDef) {
+		return ctrl.Result{}, nil
+	}
+	labels := map[string]string{
+		"app":        "filebrowser",
+		"gameserver": gs.Name,
+	}
+	values := map[string]string{
+		"username": fileBrowserAdminUser,
+		"password": "",
+	}
+	return ctrl.Result{}, r.ensureSecret(ctx, gs, fileBrowserSecretName(gs), labels, values)
+}
+
+// fileBrowserConfig returns the GameDefinition's filebrowser settings with the image defaulted.
+func fileBrowserConfig(gameDef *v1alpha1.GameDefinition) v1alpha1.FileBrowserConfig {
+	var config v1alpha1.FileBrowserConfig
+	if gameDef.Spec.FileBrowserConfig != nil {
+		config = *gameDef.Spec.FileBrowserConfig.DeepCopy()
+	}
+	if config.Image == "" {
+		config.Image = defaultFileBrowserImage
+	}
+	return config
+}
+
+// buildFileBrowserInitContainer creates a fresh filebrowser database with the admin user from the Secret.
+// In read-only mode the user may only view and download files.
+func buildFileBrowserInitContainer(gs *v1alpha1.GameServer, config v1alpha1.FileBrowserConfig) corev1.Container {
+	permissions := "--perm.admin"
+	if config.ReadOnly {
+		permissions = "--perm.create=false --perm.delete=false --perm.execute=false --perm.modify=false --perm.rename=false --perm.share=false"
+	}
+	script := strings.Join([]string{
+		"set -e",
+		"fb=$(command -v filebrowser || echo /filebrowser)",
+		"rm -f " + fileBrowserDatabasePath,
+		`"$fb" config init --database ` + fileBrowserDatabasePath,
+		`"$fb" users add "$FB_ADMIN_USERNAME" "$FB_ADMIN_PASSWORD" ` + permissions + " --database " + fileBrowserDatabasePath,
+	}, "\n")
+
+	secretKey := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: fileBrowserSecretName(gs)},
+			Key:                  key,
+		}}
+	}
+	return corev1.Container{
+		Name:    "filebrowser-init",
+		Image:   config.Image,
+		Command: []string{"sh", "-c", script},
+		Env: []corev1.EnvVar{
+			{Name: "FB_ADMIN_USERNAME", ValueFrom: secretKey("username")},
+			{Name: "FB_ADMIN_PASSWORD", ValueFrom: secretKey("password")},
+		},
+		VolumeMounts: []corev1.VolumeMount{{Name: fileBrowserDatabaseVolume, MountPath: "/database"}},
+	}
+}
+
+// buildFileBrowserContainer returns the container spec for the file browser.
+func buildFileBrowserContainer(id string, config v1alpha1.FileBrowserConfig) corev1.Container {
+	return corev1.Container{
+		Name:  "filebrowser",
+		Image: config.Image,
+		Ports: []corev1.ContainerPort{
+			{
+				ContainerPort: fileBrowserPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		Args: []string{
+			"--database", fileBrowserDatabasePath,
+			"--address", "0.0.0.0",
+			"--port", strconv.Itoa(fileBrowserPort),
+			"--baseurl", "/files/" + id,
+			"--root", "/srv",
+		},
+		Resources: config.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "game-data",
+				MountPath: "/srv",
+				ReadOnly:  config.ReadOnly,
+			},
+			{
+				Name:      fileBrowserDatabaseVolume,
+				MountPath: "/database",
+			},
+		},
+	}
+}
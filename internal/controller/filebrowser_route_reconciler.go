@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// fileBrowserServicePort is the port of the filebrowser Service.
+	fileBrowserServicePort = 8080
+	// routeSpecHashAnnotation holds the hash of the spec an Ingress or HTTPRoute was last written with. The
+	// API server fills in defaults, so comparing the specs themselves would update them on every pass.
+	routeSpecHashAnnotation = "kraftnetes.com/route-spec-hash"
+	// certManagerClusterIssuerAnnotation makes cert-manager issue the certificate of an Ingress.
+	certManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+)
+
+// httpRouteGVK is the Gateway API HTTPRoute. The operator doesn't depend on the Gateway API module, so routes
+// are handled as unstructured objects and only need the CRDs when they are actually used.
+var httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+// reconcileFileBrowserRoute routes /files/<id> to the filebrowser Service with an Ingress or an HTTPRoute,
+// depending on the GameDefinition and the operator defaults, and removes the other kind.
+func (r *GameServerReconciler) reconcileFileBrowserRoute(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	config := r.fileBrowserRouteConfig(gameDef)
+	kind := config.Kind
+	if !fileBrowserEnabled(gs, gameDef) {
+		kind = v1alpha1.FileBrowserRouteNone
+	}
+
+	id := ResolveGameServerId(gs)
+	ingressName := fmt.Sprintf("gs-%s-filebrowser-ingress", id)
+	routeName := fmt.Sprintf("gs-%s-filebrowser-route", id)
+
+	if kind != v1alpha1.FileBrowserRouteIngress {
+		if err := r.deleteFileBrowserRoute(ctx, gs, &networkingv1.Ingress{}, ingressName); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if kind != v1alpha1.FileBrowserRouteHTTPRoute {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGVK)
+		// Without the Gateway API CRDs there is no route to clean up.
+		if err := r.deleteFileBrowserRoute(ctx, gs, route, routeName); err != nil && !meta.IsNoMatchError(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var desired client.Object
+	var spec interface{}
+	switch kind {
+	case v1alpha1.FileBrowserRouteIngress:
+		ingress := buildFileBrowserIngress(gs, config)
+		desired, spec = ingress, ingress.Spec
+	case v1alpha1.FileBrowserRouteHTTPRoute:
+		if config.Gateway == nil {
+			message := "filebrowser HTTPRoute needs a Gateway, set spec.fileBrowserRoute.gateway or --filebrowser-gateway"
+			logger.Info(message)
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "FileBrowserRouteInvalid", message)
+			return ctrl.Result{}, nil
+		}
+		route := buildFileBrowserHTTPRoute(gs, config)
+		desired, spec = route, route.Object["spec"]
+	default:
+		return ctrl.Result{}, nil
+	}
+
+	hash, err := routeSpecHash(spec, desired.GetAnnotations())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	annotations := desired.GetAnnotations()
+	annotations[routeSpecHashAnnotation] = hash
+	desired.SetAnnotations(annotations)
+
+	existing := desired.DeepCopyObject().(client.Object)
+	err = r.Get(ctx, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing)
+	if meta.IsNoMatchError(err) {
+		message := "the Gateway API HTTPRoute CRD is not installed, filebrowser is only reachable through its Service"
+		logger.Info(message)
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "FileBrowserRouteUnavailable", message)
+		return ctrl.Result{}, nil
+	}
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to get filebrowser route", "kind", kind)
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "FileBrowserRouteLookupFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	if err == nil {
+		if existing.GetAnnotations()[routeSpecHashAnnotation] == hash {
+			return ctrl.Result{}, nil
+		}
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		desired.SetOwnerReferences(existing.GetOwnerReferences())
+		if err := r.Update(ctx, desired); err != nil {
+			logger.Error(err, "Failed to update filebrowser route", "kind", kind)
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "FileBrowserRouteUpdateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "FileBrowserRouteUpdated", "Updated %s %s", kind, desired.GetName())
+		return ctrl.Result{}, nil
+	}
+
+	if err := controllerutil.SetControllerReference(gs, desired, r.Scheme); err != nil {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, desired); err != nil {
+		logger.Error(err, "Failed to create filebrowser route", "kind", kind)
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "FileBrowserRouteCreateFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "FileBrowserRouteCreated", "Created %s %s", kind, desired.GetName())
+	logger.Info("Created filebrowser route", "kind", kind, "name", desired.GetName())
+	return ctrl.Result{}, nil
+}
+
+// deleteFileBrowserRoute deletes the named Ingress or HTTPRoute if it exists and is controlled by the GameServer.
+func (r *GameServerReconciler) deleteFileBrowserRoute(ctx context.Context, gs *v1alpha1.GameServer, obj client.Object, name string) error {
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: gs.Namespace}, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, gs) {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "Failed to delete filebrowser route", "name", name)
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "FileBrowserRouteDeleteFailed", err.Error())
+		return err
+	}
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "FileBrowserRouteDeleted", "Deleted %s", name)
+	return nil
+}
+
+// fileBrowserRouteConfig overlays the GameDefinition's route settings on the operator defaults.
+func (r *GameServerReconciler) fileBrowserRouteConfig(gameDef *v1alpha1.GameDefinition) v1alpha1.FileBrowserRouteConfig {
+	config := *r.FileBrowserRoute.DeepCopy()
+	override := gameDef.Spec.FileBrowserRoute
+	if override != nil {
+		if override.Kind != "" {
+			config.Kind = override.Kind
+		}
+		if override.Host != "" {
+			config.Host = override.Host
+		}
+		if override.IngressClassName != "" {
+			config.IngressClassName = override.IngressClassName
+		}
+		if override.TLSSecretName != "" {
+			config.TLSSecretName = override.TLSSecretName
+		}
+		if override.ClusterIssuer != "" {
+			config.ClusterIssuer = override.ClusterIssuer
+		}
+		if override.Gateway != nil {
+			config.Gateway = override.Gateway.DeepCopy()
+		}
+		for key, value := range override.Annotations {
+			if config.Annotations == nil {
+				config.Annotations = map[string]string{}
+			}
+			config.Annotations[key] = value
+		}
+	}
+	if config.Kind == "" {
+		config.Kind = v1alpha1.FileBrowserRouteNone
+	}
+	return config
+}
+
+// fileBrowserPath is the path filebrowser is served under, matching its --baseurl.
+func fileBrowserPath(gs *v1alpha1.GameServer) string {
+	return "/files/" + ResolveGameServerId(gs)
+}
+
+// fileBrowserRouteMeta returns the metadata shared by the filebrowser Ingress and HTTPRoute.
+func fileBrowserRouteMeta(gs *v1alpha1.GameServer, name string, config v1alpha1.FileBrowserRouteConfig) metav1.ObjectMeta {
+	annotations := map[string]string{}
+	for key, value := range config.Annotations {
+		annotations[key] = value
+	}
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: gs.Namespace,
+		Labels: map[string]string{
+			"app":        "filebrowser",
+			"gameserver": gs.Name,
+		},
+		Annotations: annotations,
+	}
+}
+
+// buildFileBrowserIngress renders the Ingress of filebrowser. With a ClusterIssuer, cert-manager issues the
+// certificate into the TLS Secret, which defaults to gs-<id>-filebrowser-tls.
+func buildFileBrowserIngress(gs *v1alpha1.GameServer, config v1alpha1.FileBrowserRouteConfig) *networkingv1.Ingress {
+	id := ResolveGameServerId(gs)
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: fileBrowserRouteMeta(gs, fmt.Sprintf("gs-%s-filebrowser-ingress", id), config),
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: config.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     fileBrowserPath(gs),
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: fmt.Sprintf("gs-%s-filebrowser-service", id),
+							Port: networkingv1.ServiceBackendPort{Number: fileBrowserServicePort},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if config.IngressClassName != "" {
+		className := config.IngressClassName
+		ingress.Spec.IngressClassName = &className
+	}
+
+	secretName := config.TLSSecretName
+	if config.ClusterIssuer != "" {
+		ingress.Annotations[certManagerClusterIssuerAnnotation] = config.ClusterIssuer
+		if secretName == "" {
+			secretName = fmt.Sprintf("gs-%s-filebrowser-tls", id)
+		}
+	}
+	if secretName != "" {
+		tls := networkingv1.IngressTLS{SecretName: secretName}
+		if config.Host != "" {
+			tls.Hosts = []string{config.Host}
+		}
+		ingress.Spec.TLS = []networkingv1.IngressTLS{tls}
+	}
+	return ingress
+}
+
+// buildFileBrowserHTTPRoute renders the HTTPRoute of filebrowser, attached to the configured Gateway.
+func buildFileBrowserHTTPRoute(gs *v1alpha1.GameServer, config v1alpha1.FileBrowserRouteConfig) *unstructured.Unstructured {
+	id := ResolveGameServerId(gs)
+
+	parentRef := map[string]interface{}{"name": config.Gateway.Name}
+	if config.Gateway.Namespace != "" {
+		parentRef["namespace"] = config.Gateway.Namespace
+	}
+	if config.Gateway.SectionName != "" {
+		parentRef["sectionName"] = config.Gateway.SectionName
+	}
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"rules": []interface{}{map[string]interface{}{
+			"matches": []interface{}{map[string]interface{}{
+				"path": map[string]interface{}{"type": "PathPrefix", "value": fileBrowserPath(gs)},
+			}},
+			"backendRefs": []interface{}{map[string]interface{}{
+				"name": fmt.Sprintf("gs-%s-filebrowser-service", id),
+				"port": int64(fileBrowserServicePort),
+			}},
+		}},
+	}
+	if config.Host != "" {
+		spec["hostnames"] = []interface{}{config.Host}
+	}
+
+	objectMeta := fileBrowserRouteMeta(gs, fmt.Sprintf("gs-%s-filebrowser-route", id), config)
+	route := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetName(objectMeta.Name)
+	route.SetNamespace(objectMeta.Namespace)
+	route.SetLabels(objectMeta.Labels)
+	route.SetAnnotations(objectMeta.Annotations)
+	return route
+}
+
+// routeSpecHash returns a short hash of a route's spec and annotations.
+func routeSpecHash(spec interface{}, annotations map[string]string) (string, error) {
+	raw, err := json.Marshal([]interface{}{spec, annotations})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal route spec: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
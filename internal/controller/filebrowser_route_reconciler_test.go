@@ -0,0 +1,79 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Filebrowser route rendering", func() {
+	gs := &kraftnetescomv1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "games", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+	}
+
+	It("lets the GameDefinition override the operator defaults", func() {
+		r := &GameServerReconciler{FileBrowserRoute: kraftnetescomv1alpha1.FileBrowserRouteConfig{
+			Kind:             kraftnetescomv1alpha1.FileBrowserRouteIngress,
+			Host:             "games.example.com",
+			IngressClassName: "nginx",
+		}}
+		config := r.fileBrowserRouteConfig(&kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			FileBrowserRoute: &kraftnetescomv1alpha1.FileBrowserRouteConfig{Host: "mc.example.com"},
+		}})
+		Expect(config.Kind).To(Equal(kraftnetescomv1alpha1.FileBrowserRouteIngress))
+		Expect(config.Host).To(Equal("mc.example.com"))
+		Expect(config.IngressClassName).To(Equal("nginx"))
+
+		Expect((&GameServerReconciler{}).fileBrowserRouteConfig(&kraftnetescomv1alpha1.GameDefinition{}).Kind).
+			To(Equal(kraftnetescomv1alpha1.FileBrowserRouteNone))
+	})
+
+	It("routes /files/<id> to the filebrowser Service with an Ingress", func() {
+		ingress := buildFileBrowserIngress(gs, kraftnetescomv1alpha1.FileBrowserRouteConfig{
+			Host:             "games.example.com",
+			IngressClassName: "nginx",
+			ClusterIssuer:    "letsencrypt",
+		})
+		Expect(ingress.Name).To(Equal("gs-abc123-filebrowser-ingress"))
+		Expect(*ingress.Spec.IngressClassName).To(Equal("nginx"))
+		Expect(ingress.Annotations).To(HaveKeyWithValue(certManagerClusterIssuerAnnotation, "letsencrypt"))
+		Expect(ingress.Spec.TLS).To(Equal([]networkingv1.IngressTLS{{Hosts: []string{"games.example.com"}, SecretName: "gs-abc123-filebrowser-tls"}}))
+
+		path := ingress.Spec.Rules[0].HTTP.Paths[0]
+		Expect(ingress.Spec.Rules[0].Host).To(Equal("games.example.com"))
+		Expect(path.Path).To(Equal("/files/abc123"))
+		Expect(path.Backend.Service.Name).To(Equal("gs-abc123-filebrowser-service"))
+		Expect(path.Backend.Service.Port.Number).To(Equal(int32(fileBrowserServicePort)))
+	})
+
+	It("routes /files/<id> to the filebrowser Service with an HTTPRoute", func() {
+		route := buildFileBrowserHTTPRoute(gs, kraftnetescomv1alpha1.FileBrowserRouteConfig{
+			Host:    "games.example.com",
+			Gateway: &kraftnetescomv1alpha1.GatewayReference{Name: "public", Namespace: "gateway"},
+		})
+		Expect(route.GetName()).To(Equal("gs-abc123-filebrowser-route"))
+		Expect(route.GroupVersionKind()).To(Equal(httpRouteGVK))
+
+		hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		Expect(hostnames).To(Equal([]string{"games.example.com"}))
+		parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+		Expect(parentRefs).To(Equal([]interface{}{map[string]interface{}{"name": "public", "namespace": "gateway"}}))
+		rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+		Expect(rules).To(HaveLen(1))
+		Expect(rules[0]).To(HaveKeyWithValue("backendRefs", []interface{}{map[string]interface{}{
+			"name": "gs-abc123-filebrowser-service",
+			"port": int64(fileBrowserServicePort),
+		}}))
+	})
+
+	It("hashes route specs together with their annotations", func() {
+		ingress := buildFileBrowserIngress(gs, kraftnetescomv1alpha1.FileBrowserRouteConfig{})
+		hash, err := routeSpecHash(ingress.Spec, ingress.Annotations)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routeSpecHash(ingress.Spec, map[string]string{"a": "b"})).NotTo(Equal(hash))
+	})
+})
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Filebrowser rendering", func() {
+	gs := &kraftnetescomv1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "survival", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+	}
+
+	It("defaults to a pinned image", func() {
+		config := fileBrowserConfig(&kraftnetescomv1alpha1.GameDefinition{})
+		Expect(config.Image).To(Equal(defaultFileBrowserImage))
+		Expect(config.Image).To(ContainSubstring(":"))
+	})
+
+	It("applies image, resources and read-only mode", func() {
+		config := fileBrowserConfig(&kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			FileBrowserConfig: &kraftnetescomv1alpha1.FileBrowserConfig{
+				Image:    "registry.example.com/filebrowser:v2.30.0",
+				ReadOnly: true,
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+				},
+			},
+		}})
+		container := buildFileBrowserContainer("abc123", config)
+		Expect(container.Image).To(Equal("registry.example.com/filebrowser:v2.30.0"))
+		Expect(container.Resources.Limits).To(HaveKey(corev1.ResourceMemory))
+		Expect(container.VolumeMounts[0].ReadOnly).To(BeTrue())
+		Expect(container.Args).To(ContainElements("--baseurl", "/files/abc123", "--database", fileBrowserDatabasePath))
+
+		initContainer := buildFileBrowserInitContainer(gs, config)
+		Expect(initContainer.Command[2]).To(ContainSubstring("--perm.modify=false"))
+		Expect(initContainer.Command[2]).NotTo(ContainSubstring("--perm.admin"))
+	})
+
+	It("creates the admin user from the generated Secret", func() {
+		initContainer := buildFileBrowserInitContainer(gs, fileBrowserConfig(&kraftnetescomv1alpha1.GameDefinition{}))
+		Expect(initContainer.Command[2]).To(ContainSubstring("--perm.admin"))
+		Expect(initContainer.Env).To(HaveLen(2))
+		for _, env := range initContainer.Env {
+			Expect(env.Value).To(BeEmpty())
+			Expect(env.ValueFrom.SecretKeyRef.Name).To(Equal("gs-abc123-filebrowser"))
+		}
+	})
+})
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Values of GamePort.Type. Ports without a type are only declared on the container.
+const (
+	portTypeHostPort     = "HostPort"
+	portTypeNodePort     = "NodePort"
+	portTypeClusterIP    = "ClusterIP"
+	portTypeLoadBalancer = "LoadBalancer"
+)
+
+// metalLBSharedIPAnnotation lets MetalLB put Services with the same key on one IP. Kubernetes can't mix
+// protocols in one LoadBalancer Service on every provider, so TCP and UDP ports get a Service each that share it.
+const metalLBSharedIPAnnotation = "metallb.universe.tf/allow-shared-ip"
+
+// reconcileGameServices creates a Service per type for the game's NodePort, ClusterIP and LoadBalancer ports,
+// removes the ones no longer needed and records their ports and external address in the GameServer status.
+func (r *GameServerReconciler) reconcileGameServices(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	desired := buildGameServices(gs, mergedConfig)
+
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services, client.InNamespace(gs.Namespace), client.MatchingLabels(gameServiceLabels(gs))); err != nil {
+		logger.Error(err, "Failed to list Services")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceLookupFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+	existing := map[string]*corev1.Service{}
+	for i := range services.Items {
+		if metav1.IsControlledBy(&services.Items[i], gs) {
+			existing[services.Items[i].Name] = &services.Items[i]
+		}
+	}
+
+	var statuses []v1alpha1.GameServiceStatus
+	for _, service := range desired {
+		current, ok := existing[service.Name]
+		delete(existing, service.Name)
+		if ok {
+			if err := r.updateGameService(ctx, gs, current, service); err != nil {
+				return ctrl.Result{}, err
+			}
+			statuses = append(statuses, gameServiceStatus(current))
+			continue
+		}
+
+		if err := controllerutil.SetControllerReference(gs, service, r.Scheme); err != nil {
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, service); err != nil {
+			logger.Error(err, "Failed to create Service")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceCreateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "ServiceCreated", "Created %s Service %s", service.Spec.Type, service.Name)
+		logger.Info("Created Service", "name", service.Name, "type", service.Spec.Type)
+		statuses = append(statuses, gameServiceStatus(service))
+	}
+
+	for _, stale := range existing {
+		if err := r.Delete(ctx, stale); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to delete Service")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceDeleteFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "ServiceDeleted", "Deleted Service %s, no port uses it anymore", stale.Name)
+	}
+
+	if reflect.DeepEqual(gs.Status.Services, statuses) {
+		return ctrl.Result{}, nil
+	}
+	gs.Status.Services = statuses
+	if err := r.Status().Update(ctx, gs); err != nil {
+		logger.Error(err, "Failed to update GameServer status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// updateGameService brings an existing Service in line with the desired one. Node ports the API server
+// assigned are kept, and annotations only get added or changed, others may belong to the load balancer.
+func (r *GameServerReconciler) updateGameService(ctx context.Context, gs *v1alpha1.GameServer, current, desired *corev1.Service) error {
+	nodePorts := map[string]int32{}
+	for _, port := range current.Spec.Ports {
+		nodePorts[port.Name] = port.NodePort
+	}
+	for i := range desired.Spec.Ports {
+		desired.Spec.Ports[i].NodePort = nodePorts[desired.Spec.Ports[i].Name]
+	}
+
+	annotationsChanged := false
+	for key, value := range desired.Annotations {
+		if current.Annotations[key] != value {
+			annotationsChanged = true
+		}
+	}
+	if !annotationsChanged &&
+		equality.Semantic.DeepEqual(current.Spec.Ports, desired.Spec.Ports) &&
+		equality.Semantic.DeepEqual(current.Spec.Selector, desired.Spec.Selector) {
+		return nil
+	}
+
+	if current.Annotations == nil && len(desired.Annotations) > 0 {
+		current.Annotations = map[string]string{}
+	}
+	for key, value := range desired.Annotations {
+		current.Annotations[key] = value
+	}
+	current.Spec.Ports = desired.Spec.Ports
+	current.Spec.Selector = desired.Spec.Selector
+	if err := r.Update(ctx, current); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update Service")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceUpdateFailed", err.Error())
+		return err
+	}
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "ServiceUpdated", "Updated Service %s", current.Name)
+	return nil
+}
+
+// buildGameServices groups the game ports by type into Services, sorted by name: gs-<id>-nodeport-service,
+// gs-<id>-clusterip-service and gs-<id>-loadbalancer-<protocol>-service.
+func buildGameServices(gs *v1alpha1.GameServer, mergedConfig v1alpha1.GameDefinitionSpec) []*corev1.Service {
+	id := ResolveGameServerId(gs)
+
+	byName := map[string]*corev1.Service{}
+	for _, gp := range mergedConfig.Ports {
+		protocol := corev1.Protocol(gp.Protocol)
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+
+		var name string
+		switch gp.Type {
+		case portTypeNodePort, portTypeClusterIP:
+			name = fmt.Sprintf("gs-%s-%s-service", id, strings.ToLower(gp.Type))
+		case portTypeLoadBalancer:
+			name = fmt.Sprintf("gs-%s-loadbalancer-%s-service", id, strings.ToLower(string(protocol)))
+		default:
+			continue
+		}
+
+		service, ok := byName[name]
+		if !ok {
+			service = &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: gs.Namespace,
+					Labels:    gameServiceLabels(gs),
+				},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{
+						"app":        "gameserver",
+						"gameserver": gs.Name,
+					},
+					Type: corev1.ServiceType(gp.Type),
+				},
+			}
+			byName[name] = service
+		}
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       gp.Name,
+			Port:       gp.ContainerPort.IntVal,
+			TargetPort: intstr.FromInt32(gp.ContainerPort.IntVal),
+			Protocol:   protocol,
+		})
+	}
+
+	names := make([]string, 0, len(byName))
+	loadBalancers := 0
+	for name, service := range byName {
+		names = append(names, name)
+		if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			loadBalancers++
+		}
+	}
+	sort.Strings(names)
+
+	services := make([]*corev1.Service, 0, len(names))
+	for _, name := range names {
+		service := byName[name]
+		if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			service.Annotations = map[string]string{}
+			if loadBalancers > 1 {
+				service.Annotations[metalLBSharedIPAnnotation] = fmt.Sprintf("%s-%s", gs.Namespace, id)
+			}
+			if mergedConfig.LoadBalancer != nil {
+				for key, value := range mergedConfig.LoadBalancer.Annotations {
+					service.Annotations[key] = value
+				}
+			}
+		}
+		services = append(services, service)
+	}
+	return services
+}
+
+// gameServiceLabels are the labels of the Services exposing a GameServer's game ports.
+func gameServiceLabels(gs *v1alpha1.GameServer) map[string]string {
+	return map[string]string{
+		"app":        "gameserver",
+		"gameserver": gs.Name,
+	}
+}
+
+// gameServiceStatus summarizes a Service for the GameServer status.
+func gameServiceStatus(service *corev1.Service) v1alpha1.GameServiceStatus {
+	status := v1alpha1.GameServiceStatus{Name: service.Name, Type: service.Spec.Type}
+	if ingress := service.Status.LoadBalancer.Ingress; len(ingress) > 0 {
+		status.ExternalAddress = ingress[0].IP
+		if status.ExternalAddress == "" {
+			status.ExternalAddress = ingress[0].Hostname
+		}
+	}
+	for _, port := range service.Spec.Ports {
+		status.Ports = append(status.Ports, v1alpha1.GameServicePortStatus{
+			Name:     port.Name,
+			Protocol: port.Protocol,
+			Port:     port.Port,
+			NodePort: port.NodePort,
+		})
+	}
+	return status
+}
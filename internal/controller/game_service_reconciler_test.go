@@ -0,0 +1,79 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Game Service rendering", func() {
+	gs := &kraftnetescomv1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "games", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+	}
+
+	port := func(name string, number int, protocol, portType string) kraftnetescomv1alpha1.GamePort {
+		return kraftnetescomv1alpha1.GamePort{Name: name, ContainerPort: intstr.FromInt(number), Protocol: protocol, Type: portType}
+	}
+
+	It("groups ports into one Service per type", func() {
+		services := buildGameServices(gs, kraftnetescomv1alpha1.GameDefinitionSpec{Ports: []kraftnetescomv1alpha1.GamePort{
+			port("minecraft", 25565, "TCP", portTypeHostPort),
+			port("query", 25565, "UDP", portTypeNodePort),
+			port("rcon", 25575, "", portTypeClusterIP),
+			port("metrics", 9225, "", portTypeClusterIP),
+		}})
+		Expect(services).To(HaveLen(2))
+		Expect(services[0].Name).To(Equal("gs-abc123-clusterip-service"))
+		Expect(services[0].Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+		Expect(services[0].Spec.Ports).To(HaveLen(2))
+		Expect(services[0].Spec.Ports[0].Protocol).To(Equal(corev1.ProtocolTCP))
+		Expect(services[1].Name).To(Equal("gs-abc123-nodeport-service"))
+		Expect(services[1].Spec.Ports[0].Protocol).To(Equal(corev1.ProtocolUDP))
+		Expect(services[1].Spec.Selector).To(Equal(map[string]string{"app": "gameserver", "gameserver": "survival"}))
+	})
+
+	It("splits LoadBalancer ports by protocol onto a shared MetalLB IP", func() {
+		services := buildGameServices(gs, kraftnetescomv1alpha1.GameDefinitionSpec{
+			Ports: []kraftnetescomv1alpha1.GamePort{
+				port("java", 25565, "TCP", portTypeLoadBalancer),
+				port("bedrock", 19132, "UDP", portTypeLoadBalancer),
+			},
+			LoadBalancer: &kraftnetescomv1alpha1.LoadBalancerConfig{
+				Annotations: map[string]string{"metallb.universe.tf/address-pool": "games"},
+			},
+		})
+		Expect(services).To(HaveLen(2))
+		Expect(services[0].Name).To(Equal("gs-abc123-loadbalancer-tcp-service"))
+		Expect(services[1].Name).To(Equal("gs-abc123-loadbalancer-udp-service"))
+		for _, service := range services {
+			Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+			Expect(service.Annotations).To(HaveKeyWithValue(metalLBSharedIPAnnotation, "games-abc123"))
+			Expect(service.Annotations).To(HaveKeyWithValue("metallb.universe.tf/address-pool", "games"))
+		}
+	})
+
+	It("reports the external address of a LoadBalancer", func() {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "gs-abc123-loadbalancer-tcp-service"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Name: "java", Port: 25565, NodePort: 31565, Protocol: corev1.ProtocolTCP}},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}},
+			}},
+		}
+		Expect(gameServiceStatus(service)).To(Equal(kraftnetescomv1alpha1.GameServiceStatus{
+			Name:            "gs-abc123-loadbalancer-tcp-service",
+			Type:            corev1.ServiceTypeLoadBalancer,
+			ExternalAddress: "192.0.2.10",
+			Ports: []kraftnetescomv1alpha1.GameServicePortStatus{
+				{Name: "java", Protocol: corev1.ProtocolTCP, Port: 25565, NodePort: 31565},
+			},
+		}))
+	})
+})
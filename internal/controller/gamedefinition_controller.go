@@ -18,11 +18,22 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
 )
@@ -30,33 +41,96 @@ import (
 // GameDefinitionReconciler reconciles a GameDefinition object
 type GameDefinitionReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=kraftnetes.com,resources=gamedefinitions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kraftnetes.com,resources=gamedefinitions/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kraftnetes.com,resources=gamedefinitions/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kraftnetes.com,resources=gameservers,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the GameDefinition object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.0/pkg/reconcile
+// Reconcile validates the GameDefinition and publishes the result, together with the
+// GameServers currently using it, in the GameDefinition status.
 func (r *GameDefinitionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
 
-	// TODO(user): your logic here
+	gameDef := &kraftnetescomv1alpha1.GameDefinition{}
+	if err := r.Get(ctx, req.NamespacedName, gameDef); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
 
+	gameServers := &kraftnetescomv1alpha1.GameServerList{}
+	// The index is registered by the GameServerReconciler, both share the manager's cache.
+	if err := r.List(ctx, gameServers, client.MatchingFields{gameServerGameIndex: gameDef.Name}); err != nil {
+		logger.Error(err, "Failed to list GameServers")
+		return ctrl.Result{}, err
+	}
+	names := make([]string, 0, len(gameServers.Items))
+	for _, gs := range gameServers.Items {
+		names = append(names, gs.Namespace+"/"+gs.Name)
+	}
+	sort.Strings(names)
+
+	desired := gameDef.DeepCopy()
+	desired.Status.ObservedGeneration = gameDef.Generation
+	desired.Status.GameServerCount = len(names)
+	desired.Status.GameServers = names
+	meta.SetStatusCondition(&desired.Status.Conditions, validCondition(gameDef))
+
+	if reflect.DeepEqual(gameDef.Status, desired.Status) {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.Status().Update(ctx, desired); err != nil {
+		logger.Error(err, "Failed to update GameDefinition status")
+		return ctrl.Result{}, err
+	}
+
+	previous := meta.FindStatusCondition(gameDef.Status.Conditions, kraftnetescomv1alpha1.ConditionValid)
+	current := meta.FindStatusCondition(desired.Status.Conditions, kraftnetescomv1alpha1.ConditionValid)
+	if current.Status == metav1.ConditionFalse && (previous == nil || previous.Message != current.Message) {
+		r.Recorder.Event(gameDef, corev1.EventTypeWarning, current.Reason, current.Message)
+	}
 	return ctrl.Result{}, nil
 }
 
+// validCondition runs validateGameDefinition and turns the outcome into the Valid condition.
+func validCondition(gameDef *kraftnetescomv1alpha1.GameDefinition) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               kraftnetescomv1alpha1.ConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "GameDefinition is valid",
+		ObservedGeneration: gameDef.Generation,
+	}
+
+	issues := validateGameDefinition(gameDef)
+	if len(issues) == 0 {
+		return condition
+	}
+
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = issues[0].Reason
+	condition.Message = fmt.Sprintf("%d problem(s): %s", len(issues), strings.Join(messages, "; "))
+	return condition
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *GameDefinitionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor("gamedefinition-controller")
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kraftnetescomv1alpha1.GameDefinition{}).
+		Watches(&kraftnetescomv1alpha1.GameServer{}, handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				gs := obj.(*kraftnetescomv1alpha1.GameServer)
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: gs.Spec.Game}}}
+			})).
 		Complete(r)
 }
@@ -22,7 +22,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,8 +39,7 @@ var _ = Describe("GameDefinition Controller", func() {
 		ctx := context.Background()
 
 		typeNamespacedName := types.NamespacedName{
-			Name:      resourceName,
-			Namespace: "default", // TODO(user):Modify as needed
+			Name: resourceName,
 		}
 		gamedefinition := &kraftnetescomv1alpha1.GameDefinition{}
 
@@ -48,10 +49,12 @@ var _ = Describe("GameDefinition Controller", func() {
 			if err != nil && errors.IsNotFound(err) {
 				resource := &kraftnetescomv1alpha1.GameDefinition{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
+						Name: resourceName,
+					},
+					Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+						Game:  resourceName,
+						Image: "itzg/minecraft-server:latest",
 					},
-					// TODO(user): Specify other spec details if needed.
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -68,17 +71,33 @@ var _ = Describe("GameDefinition Controller", func() {
 		})
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
+			// The GameServer index lives in the manager's cache, the fake client provides it here.
+			Expect(k8sClient.Get(ctx, typeNamespacedName, gamedefinition)).To(Succeed())
+			c, testScheme := newFakeClient(gamedefinition,
+				&kraftnetescomv1alpha1.GameServer{
+					ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default"},
+					Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: resourceName},
+				},
+				&kraftnetescomv1alpha1.GameServer{
+					ObjectMeta: metav1.ObjectMeta{Name: "lobby", Namespace: "default"},
+					Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "other"},
+				})
 			controllerReconciler := &GameDefinitionReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:   c,
+				Scheme:   testScheme,
+				Recorder: record.NewFakeRecorder(10),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			By("Publishing the validation result in the status")
+			Expect(c.Get(ctx, typeNamespacedName, gamedefinition)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(gamedefinition.Status.Conditions, kraftnetescomv1alpha1.ConditionValid)).To(BeTrue())
+			Expect(gamedefinition.Status.GameServerCount).To(Equal(1))
+			Expect(gamedefinition.Status.GameServers).To(Equal([]string{"default/survival"}))
 		})
 	})
 })
@@ -0,0 +1,421 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	pathpkg "path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// sha256Pattern matches a hex encoded SHA-256 checksum.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// validationIssue is a single problem found in a GameDefinition. Reason is used as the condition reason,
+// Field points at the offending part of the object for admission errors.
+type validationIssue struct {
+	Reason  string
+	Field   *field.Path
+	Message string
+}
+
+// ValidateGameDefinition returns the problems validateGameDefinition finds as field errors, for use by the
+// admission webhook.
+func ValidateGameDefinition(gameDef *v1alpha1.GameDefinition) field.ErrorList {
+	var errs field.ErrorList
+	for _, issue := range validateGameDefinition(gameDef) {
+		errs = append(errs, field.Invalid(issue.Field, field.OmitValueType{}, issue.Message))
+	}
+	return errs
+}
+
+// validateGameDefinition checks a GameDefinition for problems that would otherwise only show up
+// when a GameServer using it is reconciled.
+func validateGameDefinition(gameDef *v1alpha1.GameDefinition) []validationIssue {
+	var issues []validationIssue
+
+	for _, name := range undeclaredPlaceholders(gameDef) {
+		issues = append(issues, validationIssue{
+			Reason:  "UndeclaredPlaceholder",
+			Field:   field.NewPath("spec"),
+			Message: fmt.Sprintf("placeholder ${%s} is not declared in inputs", name),
+		})
+	}
+
+	names := make([]string, 0, len(gameDef.Inputs))
+	for name := range gameDef.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if reservedInputName(name) {
+			issues = append(issues, validationIssue{
+				Reason:  "ReservedInputName",
+				Field:   field.NewPath("inputs").Key(name),
+				Message: fmt.Sprintf("input %q uses a prefix reserved for built-in variables: %s", name, strings.Join(builtinPrefixes, ", ")),
+			})
+			continue
+		}
+		if err := validateInputSchema(gameDef.Inputs[name]); err != nil {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidInputSchema",
+				Field:   field.NewPath("inputs").Key(name),
+				Message: fmt.Sprintf("input %q: %v", name, err),
+			})
+			continue
+		}
+		if err := validateInputDefault(gameDef.Inputs[name]); err != nil {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidDefault",
+				Field:   field.NewPath("inputs").Key(name).Child("default"),
+				Message: fmt.Sprintf("input %q: %v", name, err),
+			})
+		}
+	}
+
+	if profiles := gameDef.Spec.Profiles; profiles != nil && profiles.Default != "" {
+		found := false
+		for _, profile := range profiles.Values {
+			if profile.Name == profiles.Default {
+				found = true
+				break
+			}
+		}
+		if !found {
+			issues = append(issues, validationIssue{
+				Reason:  "UnknownDefaultProfile",
+				Field:   field.NewPath("spec", "profiles", "default"),
+				Message: fmt.Sprintf("default profile %q is not defined in profiles.values", profiles.Default),
+			})
+		}
+	}
+
+	issues = append(issues, validateStorage(field.NewPath("spec", "storage"), gameDef.Spec.Storage)...)
+	issues = append(issues, validatePorts(field.NewPath("spec", "ports"), gameDef.Spec.Ports)...)
+	issues = append(issues, validateMods(field.NewPath("spec", "mods"), gameDef.Spec.Mods)...)
+	if gameDef.Spec.Profiles != nil {
+		for i, profile := range gameDef.Spec.Profiles.Values {
+			profilePath := field.NewPath("spec", "profiles", "values").Index(i)
+			issues = append(issues, validateStorage(profilePath.Child("storage"), profile.Storage)...)
+			issues = append(issues, validatePorts(profilePath.Child("ports"), profile.Ports)...)
+			issues = append(issues, validateMods(profilePath.Child("mods"), profile.Mods)...)
+		}
+	}
+
+	return issues
+}
+
+// undeclaredPlaceholders returns the sorted names of placeholders in the spec that are neither an input nor
+// a built-in variable.
+func undeclaredPlaceholders(gameDef *v1alpha1.GameDefinition) []string {
+	tree, err := decodeTree(gameDef.Spec)
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var undeclared []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			for _, child := range n {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range n {
+				walk(child)
+			}
+		case string:
+			for _, name := range templatePlaceholders(n) {
+				if _, ok := gameDef.Inputs[name]; ok || seen[name] || isBuiltinPlaceholder(gameDef, name) {
+					continue
+				}
+				seen[name] = true
+				undeclared = append(undeclared, name)
+			}
+		}
+	}
+	walk(tree)
+	sort.Strings(undeclared)
+	return undeclared
+}
+
+// validateInputDefault checks that the default value of an input, if any, parses as its declared type
+// and satisfies the input's constraints.
+func validateInputDefault(input v1alpha1.GameDefinitionInput) error {
+	switch input.Type {
+	case "", "string", "number", "boolean", secretInputType:
+	default:
+		return fmt.Errorf("unsupported type %q, must be one of string, number, boolean or secret", input.Type)
+	}
+
+	def := input.Default
+	if def.Type == v1alpha1.AnyValString && def.StrVal == "" {
+		return nil
+	}
+	if input.Type == secretInputType {
+		return fmt.Errorf("secret inputs can't have a default, leave it out to generate a value")
+	}
+	raw, ok := inputDefault(input)
+	if !ok {
+		return fmt.Errorf("default %q is not a valid %s", def.String(), input.Type)
+	}
+	if _, err := inputValue(input, raw); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	return nil
+}
+
+// validateStorage checks the sizes of a storage config and that enabled is a boolean or a placeholder. A
+// sizeLimit only applies to the emptyDir used without storage, so it can't be combined with enabled: true.
+func validateStorage(path *field.Path, storage *v1alpha1.StorageConfig) []validationIssue {
+	if storage == nil {
+		return nil
+	}
+	var issues []validationIssue
+	if storage.Enabled.Type == v1alpha1.String && len(templatePlaceholders(storage.Enabled.StrVal)) == 0 {
+		issues = append(issues, validationIssue{
+			Reason:  "InvalidStorageEnabled",
+			Field:   path.Child("enabled"),
+			Message: fmt.Sprintf("%s: enabled must be a boolean or a placeholder, got %q", path, storage.Enabled.StrVal),
+		})
+	}
+	if storage.DefaultSize != "" && len(templatePlaceholders(storage.DefaultSize)) == 0 {
+		if _, err := resource.ParseQuantity(storage.DefaultSize); err != nil {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidDefaultSize",
+				Field:   path.Child("defaultSize"),
+				Message: fmt.Sprintf("storage defaultSize %q is not a valid quantity", storage.DefaultSize),
+			})
+		}
+	}
+	if storage.SizeLimit != "" && len(templatePlaceholders(storage.SizeLimit)) == 0 {
+		if _, err := resource.ParseQuantity(storage.SizeLimit); err != nil {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidSizeLimit",
+				Field:   path.Child("sizeLimit"),
+				Message: fmt.Sprintf("storage sizeLimit %q is not a valid quantity", storage.SizeLimit),
+			})
+		}
+	}
+	if storage.SizeLimit != "" && storage.Enabled.Type == v1alpha1.Bool && storage.Enabled.BoolVal {
+		issues = append(issues, validationIssue{
+			Reason:  "SizeLimitWithStorage",
+			Field:   path.Child("sizeLimit"),
+			Message: fmt.Sprintf("%s: sizeLimit only applies without persistent storage, use defaultSize with enabled: true", path),
+		})
+	}
+	switch storage.RetainPolicy {
+	case "", v1alpha1.RetainPolicyDelete, v1alpha1.RetainPolicyRetain, v1alpha1.RetainPolicySnapshot:
+	default:
+		if len(templatePlaceholders(string(storage.RetainPolicy))) == 0 {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidRetainPolicy",
+				Field:   path.Child("retainPolicy"),
+				Message: fmt.Sprintf("%s: unknown retainPolicy %q, expected Delete, Retain or Snapshot", path, storage.RetainPolicy),
+			})
+		}
+	}
+	if storage.Backup != nil {
+		issues = append(issues, validateBackupSchedule(path.Child("backup"), storage.Backup)...)
+	}
+	return append(issues, validateVolumeClaimOptions(path, storage.VolumeClaimOptions)...)
+}
+
+// validateBackupSchedule checks the cron schedule and the target of scheduled backups.
+func validateBackupSchedule(path *field.Path, schedule *v1alpha1.BackupSchedule) []validationIssue {
+	var issues []validationIssue
+	if len(templatePlaceholders(schedule.Schedule)) == 0 {
+		if _, err := parseCronSchedule(schedule.Schedule); err != nil {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidSchedule",
+				Field:   path.Child("schedule"),
+				Message: fmt.Sprintf("%s: invalid schedule %q: %s", path, schedule.Schedule, err.Error()),
+			})
+		}
+	}
+	if err := validateBackupTarget(scheduledBackupTarget(schedule)); err != nil {
+		issues = append(issues, validationIssue{
+			Reason:  "InvalidBackupTarget",
+			Field:   path.Child("target"),
+			Message: fmt.Sprintf("%s: %s", path, err.Error()),
+		})
+	}
+	return issues
+}
+
+// validateVolumeClaimOptions checks that the claim can hold the game data: it must be writable and mountable
+// as a file system at /data.
+func validateVolumeClaimOptions(path *field.Path, options v1alpha1.VolumeClaimOptions) []validationIssue {
+	var issues []validationIssue
+	for i, mode := range options.AccessModes {
+		switch mode {
+		case corev1.ReadWriteOnce, corev1.ReadWriteMany, corev1.ReadWriteOncePod:
+		default:
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidAccessMode",
+				Field:   path.Child("accessModes").Index(i),
+				Message: fmt.Sprintf("%s: access mode %q can't be used for game data, expected ReadWriteOnce, ReadWriteMany or ReadWriteOncePod", path, mode),
+			})
+		}
+	}
+	if options.VolumeMode != nil && *options.VolumeMode != corev1.PersistentVolumeFilesystem {
+		issues = append(issues, validationIssue{
+			Reason:  "UnsupportedVolumeMode",
+			Field:   path.Child("volumeMode"),
+			Message: fmt.Sprintf("%s: volumeMode %q is not supported, the game data is mounted as a file system at /data", path, *options.VolumeMode),
+		})
+	}
+	if options.Seed != nil {
+		issues = append(issues, validateSeed(path.Child("seed"), options.Seed)...)
+	}
+	return issues
+}
+
+// validatePorts checks that no game port uses the filebrowser port and that string ports are numeric or placeholders.
+func validatePorts(path *field.Path, ports []v1alpha1.GamePort) []validationIssue {
+	var issues []validationIssue
+	for i, port := range ports {
+		switch port.Type {
+		case "", portTypeHostPort, portTypeNodePort, portTypeClusterIP, portTypeLoadBalancer:
+		default:
+			if len(templatePlaceholders(port.Type)) == 0 {
+				issues = append(issues, validationIssue{
+					Reason:  "InvalidPortType",
+					Field:   path.Index(i).Child("type"),
+					Message: fmt.Sprintf("%s: port %q has unknown type %q, expected HostPort, NodePort, ClusterIP or LoadBalancer", path, port.Name, port.Type),
+				})
+			}
+		}
+
+		value := port.ContainerPort
+		if value.Type == intstr.String {
+			if len(templatePlaceholders(value.StrVal)) > 0 {
+				continue
+			}
+			n, err := strconv.Atoi(value.StrVal)
+			if err != nil {
+				issues = append(issues, validationIssue{
+					Reason:  "InvalidPort",
+					Field:   path.Index(i).Child("containerPort"),
+					Message: fmt.Sprintf("%s: port %q has non-numeric containerPort %q", path, port.Name, value.StrVal),
+				})
+				continue
+			}
+			value = intstr.FromInt(n)
+		}
+		if value.IntVal == fileBrowserPort {
+			issues = append(issues, validationIssue{
+				Reason:  "ReservedPort",
+				Field:   path.Index(i).Child("containerPort"),
+				Message: fmt.Sprintf("%s: port %q uses %d, which is reserved for filebrowser", path, port.Name, fileBrowserPort),
+			})
+		}
+	}
+	return issues
+}
+
+// validateSeed checks that exactly one seed source is set and that downloads can be verified.
+func validateSeed(path *field.Path, seed *v1alpha1.SeedSource) []validationIssue {
+	var issues []validationIssue
+	sources := 0
+	for _, set := range []bool{seed.HTTP != nil, seed.ConfigMap != nil, seed.Secret != nil, seed.PVC != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return append(issues, validationIssue{
+			Reason:  "InvalidSeed",
+			Field:   path,
+			Message: fmt.Sprintf("%s: exactly one of http, configMap, secret or pvc must be set", path),
+		})
+	}
+
+	switch {
+	case seed.HTTP != nil:
+		if len(templatePlaceholders(seed.HTTP.URL)) == 0 {
+			if u, err := url.Parse(seed.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				issues = append(issues, validationIssue{
+					Reason:  "InvalidSeed",
+					Field:   path.Child("http", "url"),
+					Message: fmt.Sprintf("%s: url %q must be an http or https URL", path, seed.HTTP.URL),
+				})
+			}
+		}
+		if len(templatePlaceholders(seed.HTTP.SHA256)) == 0 && !sha256Pattern.MatchString(seed.HTTP.SHA256) {
+			issues = append(issues, validationIssue{
+				Reason:  "InvalidSeed",
+				Field:   path.Child("http", "sha256"),
+				Message: fmt.Sprintf("%s: sha256 must be the 64 hex digit checksum of the download", path),
+			})
+		}
+	case seed.ConfigMap != nil && seed.ConfigMap.Name == "":
+		issues = append(issues, validationIssue{Reason: "InvalidSeed", Field: path.Child("configMap", "name"), Message: fmt.Sprintf("%s: configMap needs a name", path)})
+	case seed.Secret != nil && seed.Secret.Name == "":
+		issues = append(issues, validationIssue{Reason: "InvalidSeed", Field: path.Child("secret", "name"), Message: fmt.Sprintf("%s: secret needs a name", path)})
+	case seed.PVC != nil && seed.PVC.ClaimName == "":
+		issues = append(issues, validationIssue{Reason: "InvalidSeed", Field: path.Child("pvc", "claimName"), Message: fmt.Sprintf("%s: pvc needs a claimName", path)})
+	}
+	return issues
+}
+
+// validateMods checks that every mod can be downloaded and verified, and that names and paths are unique. Paths
+// must stay within the game data and leave the operator's own files alone.
+func validateMods(path *field.Path, mods []v1alpha1.Mod) []validationIssue {
+	var issues []validationIssue
+	invalid := func(fieldPath *field.Path, format string, args ...interface{}) {
+		issues = append(issues, validationIssue{
+			Reason:  "InvalidMod",
+			Field:   fieldPath,
+			Message: fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)),
+		})
+	}
+
+	names := map[string]bool{}
+	paths := map[string]bool{}
+	for i, mod := range mods {
+		modPath := path.Index(i)
+		switch {
+		case mod.Name == "" || strings.ContainsAny(mod.Name, " \t\n"):
+			invalid(modPath.Child("name"), "mod name %q must be non-empty without whitespace", mod.Name)
+		case names[mod.Name]:
+			invalid(modPath.Child("name"), "mod %q is listed twice", mod.Name)
+		}
+		names[mod.Name] = true
+
+		if len(templatePlaceholders(mod.URL)) == 0 {
+			if u, err := url.Parse(mod.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(mod.URL, " \t\n") {
+				invalid(modPath.Child("url"), "mod %s: url %q must be an http or https URL", mod.Name, mod.URL)
+			}
+		}
+		if len(templatePlaceholders(mod.SHA256)) == 0 && !sha256Pattern.MatchString(mod.SHA256) {
+			invalid(modPath.Child("sha256"), "mod %s: sha256 must be the 64 hex digit checksum of the file", mod.Name)
+		}
+
+		if len(templatePlaceholders(mod.Path)) > 0 {
+			continue
+		}
+		switch {
+		case mod.Path == "" || strings.ContainsAny(mod.Path, " \t\n"):
+			invalid(modPath.Child("path"), "mod %s: path %q must be non-empty without whitespace", mod.Name, mod.Path)
+		case pathpkg.IsAbs(mod.Path) || pathpkg.Clean(mod.Path) != mod.Path || mod.Path == ".." || strings.HasPrefix(mod.Path, "../"):
+			invalid(modPath.Child("path"), "mod %s: path %q must be a clean path relative to /data", mod.Name, mod.Path)
+		case strings.HasPrefix(mod.Path, ".kraftnetes"):
+			invalid(modPath.Child("path"), "mod %s: path %q is reserved for the operator", mod.Name, mod.Path)
+		case paths[mod.Path]:
+			invalid(modPath.Child("path"), "mod %s: path %q is used by another mod", mod.Name, mod.Path)
+		}
+		paths[mod.Path] = true
+	}
+	return issues
+}
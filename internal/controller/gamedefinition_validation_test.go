@@ -0,0 +1,169 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("GameDefinition validation", func() {
+	var gameDef *kraftnetescomv1alpha1.GameDefinition
+
+	reasons := func() []string {
+		var out []string
+		for _, issue := range validateGameDefinition(gameDef) {
+			out = append(out, issue.Reason)
+		}
+		return out
+	}
+
+	BeforeEach(func() {
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{
+			Inputs: map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+				"version": {Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "1.21.5"}},
+			},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:  "minecraft",
+				Image: "itzg/minecraft-server:${version}",
+				Ports: []kraftnetescomv1alpha1.GamePort{{Name: "minecraft", ContainerPort: intstr.FromInt(25565)}},
+			},
+		}
+	})
+
+	It("accepts a consistent definition", func() {
+		Expect(validateGameDefinition(gameDef)).To(BeEmpty())
+	})
+
+	It("flags placeholders that are not declared as inputs", func() {
+		gameDef.Spec.Image = "itzg/minecraft-server:${tag}"
+		Expect(reasons()).To(ConsistOf("UndeclaredPlaceholder"))
+	})
+
+	It("ignores escaped placeholders", func() {
+		gameDef.Spec.Env = []corev1.EnvVar{{Name: "MOTD", Value: "$${tag}"}}
+		Expect(reasons()).To(BeEmpty())
+	})
+
+	It("flags defaults that don't parse as their type", func() {
+		gameDef.Inputs["players"] = kraftnetescomv1alpha1.GameDefinitionInput{
+			Type:    "number",
+			Default: kraftnetescomv1alpha1.AnyVal{StrVal: "lots"},
+		}
+		Expect(reasons()).To(ConsistOf("InvalidDefault"))
+	})
+
+	It("flags an unknown default profile", func() {
+		gameDef.Spec.Profiles = &kraftnetescomv1alpha1.GameProfiles{
+			Default: "paper",
+			Values:  []kraftnetescomv1alpha1.GameProfile{{Name: "vanilla"}},
+		}
+		Expect(reasons()).To(ConsistOf("UnknownDefaultProfile"))
+	})
+
+	It("flags ports colliding with filebrowser", func() {
+		gameDef.Spec.Ports = append(gameDef.Spec.Ports,
+			kraftnetescomv1alpha1.GamePort{Name: "web", ContainerPort: intstr.FromString("8077")})
+		Expect(reasons()).To(ConsistOf("ReservedPort"))
+	})
+
+	It("accepts built-in variables for declared ports", func() {
+		gameDef.Spec.Ports[0].Type = "HostPort"
+		gameDef.Spec.Env = []corev1.EnvVar{
+			{Name: "SERVER_NAME", Value: "${gs.name}"},
+			{Name: "SERVER_PORT", Value: "${ports.minecraft.hostPort}"},
+			{Name: "MEMORY", Value: "${resources.limits.memoryMi}M"},
+		}
+		Expect(reasons()).To(BeEmpty())
+	})
+
+	It("flags host ports of unknown ports", func() {
+		gameDef.Spec.Env = []corev1.EnvVar{{Name: "SERVER_PORT", Value: "${ports.query.hostPort}"}}
+		Expect(reasons()).To(ConsistOf("UndeclaredPlaceholder"))
+	})
+
+	It("flags inputs named like built-in variables", func() {
+		gameDef.Inputs["gs.name"] = kraftnetescomv1alpha1.GameDefinitionInput{Type: "string"}
+		Expect(reasons()).To(ConsistOf("ReservedInputName"))
+	})
+
+	It("flags unknown port types", func() {
+		gameDef.Spec.Ports[0].Type = "Ingress"
+		Expect(reasons()).To(ConsistOf("InvalidPortType"))
+	})
+
+	It("flags storage settings that can't work", func() {
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{
+			Enabled:   kraftnetescomv1alpha1.FromString("yes"),
+			SizeLimit: "lots",
+		}
+		Expect(reasons()).To(ConsistOf("InvalidStorageEnabled", "InvalidSizeLimit"))
+
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromBool(true), SizeLimit: "1Gi"}
+		Expect(reasons()).To(ConsistOf("SizeLimitWithStorage"))
+
+		gameDef.Inputs["persist"] = kraftnetescomv1alpha1.GameDefinitionInput{Type: "boolean"}
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromString("${persist}"), SizeLimit: "1Gi"}
+		Expect(reasons()).To(BeEmpty())
+	})
+
+	It("flags claims that can't hold the game data", func() {
+		block := corev1.PersistentVolumeBlock
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{
+			Enabled: kraftnetescomv1alpha1.FromBool(true),
+			VolumeClaimOptions: kraftnetescomv1alpha1.VolumeClaimOptions{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadOnlyMany},
+				VolumeMode:  &block,
+			},
+		}
+		Expect(reasons()).To(ConsistOf("InvalidAccessMode", "UnsupportedVolumeMode"))
+	})
+
+	It("flags backup schedules that can't run", func() {
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{
+			Enabled: kraftnetescomv1alpha1.FromBool(true),
+			Backup: &kraftnetescomv1alpha1.BackupSchedule{
+				Schedule: "every night",
+				Target:   kraftnetescomv1alpha1.BackupTarget{S3: &kraftnetescomv1alpha1.S3BackupTarget{Bucket: "worlds"}},
+			},
+		}
+		Expect(reasons()).To(ConsistOf("InvalidSchedule", "InvalidBackupTarget"))
+
+		gameDef.Spec.Storage.Backup = &kraftnetescomv1alpha1.BackupSchedule{Schedule: "0 0 30 2 *"}
+		Expect(reasons()).To(ConsistOf("InvalidSchedule"))
+
+		gameDef.Spec.Storage.Backup = &kraftnetescomv1alpha1.BackupSchedule{Schedule: "@daily"}
+		Expect(reasons()).To(BeEmpty())
+	})
+	It("flags seeds that can't be verified", func() {
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{
+			Enabled: kraftnetescomv1alpha1.FromBool(true),
+			VolumeClaimOptions: kraftnetescomv1alpha1.VolumeClaimOptions{Seed: &kraftnetescomv1alpha1.SeedSource{
+				HTTP: &kraftnetescomv1alpha1.HTTPSeedSource{URL: "ftp://example.com/world.zip"},
+			}},
+		}
+		Expect(reasons()).To(ConsistOf("InvalidSeed", "InvalidSeed"))
+
+		gameDef.Spec.Storage.Seed.PVC = &kraftnetescomv1alpha1.PVCSeedSource{ClaimName: "worlds"}
+		Expect(reasons()).To(ConsistOf("InvalidSeed"))
+
+		gameDef.Spec.Storage.Seed = &kraftnetescomv1alpha1.SeedSource{HTTP: &kraftnetescomv1alpha1.HTTPSeedSource{
+			URL:    "https://example.com/lobby.zip",
+			SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		}}
+		Expect(reasons()).To(BeEmpty())
+	})
+	It("flags mods that can't be verified or escape the game data", func() {
+		gameDef.Spec.Mods = []kraftnetescomv1alpha1.Mod{
+			{Name: "essentials", URL: "https://example.com/EssentialsX.jar", SHA256: "abc", Path: "plugins/EssentialsX.jar"},
+			{Name: "essentials", URL: "https://example.com/Other.jar", SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", Path: "../Other.jar"},
+		}
+		Expect(reasons()).To(ConsistOf("InvalidMod", "InvalidMod", "InvalidMod"))
+
+		gameDef.Spec.Mods = gameDef.Spec.Mods[1:]
+		gameDef.Spec.Mods[0].Path = "plugins/Other.jar"
+		Expect(reasons()).To(BeEmpty())
+	})
+})
@@ -2,32 +2,59 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// gameServerGameIndex indexes GameServers by the name of the GameDefinition they reference.
+	gameServerGameIndex = "spec.game"
+	// gameServerHostPortsIndex indexes the GameServers holding host ports under "true".
+	gameServerHostPortsIndex = "status.hostPorts"
 )
 
 type GameServerReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	Executor PodExecutor
+	// HostPorts allocates the host ports of HostPort ports. SetupWithManager defaults it to DefaultHostPortRange.
+	HostPorts *HostPortAllocator
+	// FileBrowserRoute holds the operator defaults for the filebrowser Ingress or HTTPRoute, which
+	// GameDefinitions can override.
+	FileBrowserRoute v1alpha1.FileBrowserRouteConfig
+	// BackupImage and BackupS3Image run the init containers restoring backups. SetupWithManager defaults them
+	// to DefaultBackupImage and DefaultBackupS3Image.
+	BackupImage   string
+	BackupS3Image string
+	// SeedImage runs the init containers seeding new game data and syncing mods. SetupWithManager defaults it
+	// to DefaultSeedImage.
+	SeedImage string
 }
 
 // +kubebuilder:rbac:groups=kraftnetes.com,resources=gameservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kraftnetes.com,resources=gameservers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kraftnetes.com,resources=gameservers/finalizers,verbs=update
-// +kubebuilder:rbac:groups="",resources=pods;services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods;services;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods/exec;pods/attach,verbs=create
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=kraftnetes.com,resources=gameserverbackups,verbs=get;list;watch;create;delete
 
 func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -37,21 +64,51 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !gameServer.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, gameServer)
+	}
+	if controllerutil.AddFinalizer(gameServer, gameServerFinalizer) {
+		if err := r.Update(ctx, gameServer); err != nil {
+			logger.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
 	gameDef := &v1alpha1.GameDefinition{}
 	if err := r.Get(ctx, types.NamespacedName{Name: gameServer.Spec.Game}, gameDef); err != nil {
-		logger.Error(err, "GameDefinition not found", "game", gameServer.Spec.Game)
-		r.Recorder.Event(gameServer, corev1.EventTypeWarning, "GameDefinitionNotFound",
-			fmt.Sprintf("GameDefinition %s not found", gameServer.Spec.Game))
-		// Requeue until the GameDefinition is available.
-		return ctrl.Result{Requeue: true}, nil
-	}
-
-	// --- VARIABLE SUBSTITUTION SECTION ---
-	// Walk through gameDef.Spec and replace every occurrence of &{variable}
-	// with its corresponding value provided in gameServer.Spec.Inputs or,
-	// if absent, the default in gameDef.Inputs. If any required variable is missing,
-	// or if any placeholder remains unresolved, return an error.
-	resolvedSpec, err := resolveGameDefinitionSpec(gameDef.Spec, gameServer.Spec.Inputs, gameDef.Inputs)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to get GameDefinition", "game", gameServer.Spec.Game)
+			return ctrl.Result{}, err
+		}
+		message := fmt.Sprintf("GameDefinition %s not found", gameServer.Spec.Game)
+		logger.Info(message)
+		r.Recorder.Event(gameServer, corev1.EventTypeWarning, "GameDefinitionNotFound", message)
+		// No requeue: the GameDefinition watch brings this GameServer back once the definition exists.
+		return ctrl.Result{}, r.setState(ctx, gameServer, v1alpha1.GameServerPending, message)
+	}
+
+	// The admission webhook runs the same checks; this catches GameServers created while it was disabled
+	// or that a later GameDefinition change invalidated.
+	errs := ValidateGameServer(gameServer, gameDef)
+	if err := r.setValidCondition(ctx, gameServer, errs); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(errs) > 0 {
+		message := errs.ToAggregate().Error()
+		logger.Info("GameServer is invalid", "errors", message)
+		r.Recorder.Event(gameServer, corev1.EventTypeWarning, "InvalidSpec", message)
+		// No requeue: fixing the GameServer or its GameDefinition triggers a new reconcile.
+		return ctrl.Result{}, nil
+	}
+
+	// Host ports are allocated before rendering, templates can refer to them as ${ports.<name>.hostPort}.
+	if res, err := r.reconcileHostPorts(ctx, gameServer, gameDef); err != nil || !res.IsZero() {
+		return res, err
+	}
+
+	// Substitute the ${input} placeholders in the GameDefinition spec with the GameServer's inputs
+	// or their defaults. Every placeholder that can't be resolved is reported with its path.
+	resolvedSpec, err := resolveGameDefinitionSpec(gameServer, gameDef)
 	if err != nil {
 		logger.Error(err, "Failed to resolve GameDefinition spec variables")
 		r.Recorder.Event(gameServer, corev1.EventTypeWarning, "UnresolvedVariables", err.Error())
@@ -59,15 +116,19 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 	// Replace the GameDefinition spec with the fully resolved version.
 	gameDef.Spec = resolvedSpec
-	// --- END VARIABLE SUBSTITUTION SECTION ---
 
 	for _, sub := range r.subReconcilers() {
 		if res, err := sub(ctx, gameServer, gameDef); err != nil || !res.IsZero() {
 			if err != nil {
 				logger.Error(err, "Subreconciler failed")
 				r.Recorder.Event(gameServer, corev1.EventTypeWarning, "SubreconcileError", err.Error())
+				return res, err
 			}
-			return res, err
+			// Keep the reported state current while a step is waiting, e.g. on a graceful stop.
+			if _, err := r.updateStatus(ctx, gameServer, gameDef); err != nil {
+				return ctrl.Result{}, err
+			}
+			return res, nil
 		}
 	}
 
@@ -82,144 +143,89 @@ func ResolveGameServerId(gs *v1alpha1.GameServer) string {
 	return id
 }
 
-// resolveGameDefinitionSpec performs a generic substitution on the entire GameDefinitionSpec.
-// It replaces every occurrence of &{variable} with the corresponding value from gsInputs or, if absent,
-// from defInputs (which contains default values). If a variable is required but neither provided nor defaulted,
-// or if any placeholder remains, an error is returned.
-func resolveGameDefinitionSpec(spec v1alpha1.GameDefinitionSpec, gsInputs map[string]apiextensionsv1.JSON, defInputs map[string]v1alpha1.GameDefinitionInput) (v1alpha1.GameDefinitionSpec, error) {
-	// Marshal the spec to JSON string
-	rawBytes, err := json.Marshal(spec)
-	if err != nil {
-		return spec, fmt.Errorf("failed to marshal GameDefinition spec: %w", err)
-	}
-	specStr := string(rawBytes)
-
-	// Build substitution map
-	subs := make(map[string]string)
-	for key, input := range defInputs {
-		var value string
-		if gsInputs != nil {
-			if j, ok := gsInputs[key]; ok {
-				value = jsonValueToString(j)
-			}
-		}
-
-		// If no value from GameServer, use default
-		if value == "" {
-			var defaultStr string
-			switch input.Type {
-			case "string":
-				if input.Default.Type == v1alpha1.AnyValString {
-					defaultStr = input.Default.StrVal
-				} else {
-					defaultStr = input.Default.String()
-				}
-			case "number":
-				if input.Default.Type == v1alpha1.AnyValNumber {
-					defaultStr = strconv.FormatFloat(input.Default.NumVal, 'f', -1, 64)
-				} else if input.Default.Type == v1alpha1.AnyValString {
-					if num, err := strconv.ParseFloat(input.Default.StrVal, 64); err == nil {
-						defaultStr = strconv.FormatFloat(num, 'f', -1, 64)
-					} else {
-						return spec, fmt.Errorf("default value for variable %q is not a valid number", key)
-					}
-				} else {
-					return spec, fmt.Errorf("default value for variable %q is not a valid number", key)
-				}
-			case "boolean":
-				if input.Default.Type == v1alpha1.AnyValBool {
-					defaultStr = strconv.FormatBool(input.Default.BoolVal)
-				} else if input.Default.Type == v1alpha1.AnyValString {
-					if b, err := strconv.ParseBool(input.Default.StrVal); err == nil {
-						defaultStr = strconv.FormatBool(b)
-					} else {
-						return spec, fmt.Errorf("default value for variable %q is not a valid boolean", key)
-					}
-				} else {
-					return spec, fmt.Errorf("default value for variable %q is not a valid boolean", key)
-				}
-			default:
-				defaultStr = input.Default.String()
-			}
-			if defaultStr == "" {
-				return spec, fmt.Errorf("variable %q is required but not provided and no default is available", key)
-			}
-			value = defaultStr
-		}
-
-		subs[key] = value
-	}
-
-	// Perform placeholder substitution: look for ${key}
-	for key, value := range subs {
-		placeholder := "${" + key + "}"
-		fmt.Printf("[DEBUG] Replacing placeholder: %s -> value: %s\n", placeholder, value)
-
-		// Detect if value looks like boolean or number
-		if value == "true" || value == "false" || isNumeric(value) {
-			// Replace placeholder without quotes
-			specStr = strings.ReplaceAll(specStr, "\""+placeholder+"\"", value)
-		} else {
-			// Replace normally
-			specStr = strings.ReplaceAll(specStr, placeholder, value)
-		}
-	}
-
-	// Check if any unresolved placeholders remain
-	if strings.Contains(specStr, "${") {
-		return spec, fmt.Errorf("unresolved variables remain in GameDefinition spec: %s", specStr)
-	}
-
-	// Unmarshal back into GameDefinitionSpec
-	var resolvedSpec v1alpha1.GameDefinitionSpec
-	if err := json.Unmarshal([]byte(specStr), &resolvedSpec); err != nil {
-		return spec, fmt.Errorf("failed to unmarshal resolved GameDefinition spec: %w", err)
-	}
-
-	// Debug prints: show fully resolved spec
-	fmt.Printf("[DEBUG] RESOLVED RAW SPEC STRING = %s\n", specStr)
-
-	resolvedBytes, err := json.Marshal(resolvedSpec)
-	if err != nil {
-		fmt.Printf("[DEBUG] Failed to marshal resolvedSpec for debug print: %v\n", err)
-	} else {
-		fmt.Printf("[DEBUG] RESOLVED OBJECT (marshalled back to JSON) = %s\n", string(resolvedBytes))
-	}
-
-	return resolvedSpec, nil
-}
-
-func isNumeric(s string) bool {
-	_, err := strconv.ParseFloat(s, 64)
-	return err == nil
-}
-
-// jsonValueToString converts an apiextensionsv1.JSON value to its string representation.
-func jsonValueToString(j apiextensionsv1.JSON) string {
-	var v interface{}
-	if err := json.Unmarshal(j.Raw, &v); err != nil {
-		return ""
-	}
-	return fmt.Sprintf("%v", v)
-}
-
 func (r *GameServerReconciler) subReconcilers() []func(context.Context, *v1alpha1.GameServer, *v1alpha1.GameDefinition) (ctrl.Result, error) {
 	return []func(context.Context, *v1alpha1.GameServer, *v1alpha1.GameDefinition) (ctrl.Result, error){
 		r.reconcileInitialStatus,
+		r.reconcileInputSecret,
+		r.reconcileFileBrowserSecret,
 		r.reconcileService,
+		r.reconcileGameServices,
+		r.reconcileFileBrowserRoute,
 		r.reconcilePvc,
+		r.reconcileRestore,
+		r.reconcileRestart,
 		r.reconcilePod,
+		r.reconcileSeed,
+		r.reconcileMods,
+		r.reconcileBackupSchedule,
 		r.updateStatus,
 	}
 }
 
 func (r *GameServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor("gameserver-controller")
+	if r.BackupImage == "" {
+		r.BackupImage = DefaultBackupImage
+	}
+	if r.BackupS3Image == "" {
+		r.BackupS3Image = DefaultBackupS3Image
+	}
+	if r.SeedImage == "" {
+		r.SeedImage = DefaultSeedImage
+	}
+	if r.HostPorts == nil {
+		first, last, err := ParseHostPortRange(DefaultHostPortRange)
+		if err != nil {
+			return err
+		}
+		if r.HostPorts, err = NewHostPortAllocator(first, last); err != nil {
+			return err
+		}
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.GameServer{}, gameServerGameIndex, gameServerGame); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.GameServer{}, gameServerHostPortsIndex, gameServerHostPorts); err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.GameServer{}).
 		Owns(&corev1.Pod{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&corev1.Secret{}).
+		Owns(&networkingv1.Ingress{}).
+		Watches(&v1alpha1.GameDefinition{}, handler.EnqueueRequestsFromMapFunc(r.gameServersForGameDefinition)).
+		Watches(&v1alpha1.GameServerBackup{}, handler.EnqueueRequestsFromMapFunc(r.gameServerForScheduledBackup)).
 		Complete(r)
 }
+
+// gameServerGame extracts the gameServerGameIndex value of a GameServer.
+func gameServerGame(obj client.Object) []string {
+	return []string{obj.(*v1alpha1.GameServer).Spec.Game}
+}
+
+// gameServerHostPorts extracts the gameServerHostPortsIndex value of a GameServer.
+func gameServerHostPorts(obj client.Object) []string {
+	if len(obj.(*v1alpha1.GameServer).Status.HostPorts) == 0 {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// gameServersForGameDefinition maps a GameDefinition to reconcile requests for every GameServer referencing it.
+func (r *GameServerReconciler) gameServersForGameDefinition(ctx context.Context, obj client.Object) []reconcile.Request {
+	gameServers := &v1alpha1.GameServerList{}
+	if err := r.List(ctx, gameServers, client.MatchingFields{gameServerGameIndex: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list GameServers for GameDefinition", "gameDefinition", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(gameServers.Items))
+	for _, gs := range gameServers.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&gs)})
+	}
+	return requests
+}
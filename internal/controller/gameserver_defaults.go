@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// DefaultGameServer fills the fields of a GameServer that would otherwise be derived from its GameDefinition
+// on every reconcile: profile, input defaults, filebrowser and volumeSize. Fields that are already set are
+// left alone, so later changes to the GameDefinition's defaults don't affect existing GameServers.
+func DefaultGameServer(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) {
+	if gs.Spec.Profile == "" && gameDef.Spec.Profiles != nil && gameDef.Spec.Profiles.Default != "" {
+		gs.Spec.Profile = gameDef.Spec.Profiles.Default
+	}
+
+	for name, input := range gameDef.Inputs {
+		if _, ok := gs.Spec.Inputs[name]; ok {
+			continue
+		}
+		value, ok := inputDefault(input)
+		if !ok {
+			continue
+		}
+		if gs.Spec.Inputs == nil {
+			gs.Spec.Inputs = map[string]apiextensionsv1.JSON{}
+		}
+		gs.Spec.Inputs[name] = value
+	}
+
+	// filebrowser and storage may be placeholders, so look at them after substitution. If the spec
+	// doesn't resolve the validating webhook rejects the GameServer anyway.
+	resolved := gameDef.DeepCopy()
+	spec, err := resolveGameDefinitionSpec(gs, gameDef)
+	if err != nil {
+		return
+	}
+	resolved.Spec = spec
+
+	if gs.Spec.Filebrowser == nil {
+		enabled := fileBrowserEnabled(gs, resolved)
+		gs.Spec.Filebrowser = &enabled
+	}
+
+	if gs.Spec.VolumeSize == "" && usesStorage(gs, resolved) {
+		if size, err := resolveVolumeSize(gs, resolved); err == nil {
+			gs.Spec.VolumeSize = size.String()
+		}
+	}
+}
+
+// inputDefault returns the default of an input as JSON of the input's type. It returns false if the input
+// has no default or the default doesn't parse as the type.
+func inputDefault(input v1alpha1.GameDefinitionInput) (apiextensionsv1.JSON, bool) {
+	def := input.Default
+	if def.Type == v1alpha1.AnyValString && def.StrVal == "" {
+		return apiextensionsv1.JSON{}, false
+	}
+
+	var value interface{}
+	switch input.Type {
+	case "number":
+		switch def.Type {
+		case v1alpha1.AnyValNumber:
+			value = def.NumVal
+		case v1alpha1.AnyValString:
+			n, err := strconv.ParseFloat(def.StrVal, 64)
+			if err != nil {
+				return apiextensionsv1.JSON{}, false
+			}
+			value = n
+		default:
+			return apiextensionsv1.JSON{}, false
+		}
+	case "boolean":
+		switch def.Type {
+		case v1alpha1.AnyValBool:
+			value = def.BoolVal
+		case v1alpha1.AnyValString:
+			b, err := strconv.ParseBool(def.StrVal)
+			if err != nil {
+				return apiextensionsv1.JSON{}, false
+			}
+			value = b
+		default:
+			return apiextensionsv1.JSON{}, false
+		}
+	default:
+		value = def.String()
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return apiextensionsv1.JSON{}, false
+	}
+	return apiextensionsv1.JSON{Raw: raw}, true
+}
+
+// fileBrowserEnabled reports whether the GameServer runs a filebrowser: its own flag if set,
+// else the flag of its profile or GameDefinition.
+func fileBrowserEnabled(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) bool {
+	if gs.Spec.Filebrowser != nil {
+		return *gs.Spec.Filebrowser
+	}
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	return mergedConfig.FileBrowser.BoolVal
+}
+
+// storageConfig returns the storage settings of the GameServer's profile or GameDefinition, or nil if
+// there are none.
+func storageConfig(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) *v1alpha1.StorageConfig {
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	return mergedConfig.Storage
+}
+
+// usesStorage reports whether the GameServer gets a persistent game data volume. Without one the game
+// data lives in an emptyDir.
+func usesStorage(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) bool {
+	storage := storageConfig(gs, gameDef)
+	return storage != nil && storage.Enabled.Type == v1alpha1.Bool && storage.Enabled.BoolVal
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateGameServer checks a GameServer against its GameDefinition with the same resolution code the
+// reconciler uses, so anything it accepts can be rendered. It is shared by the admission webhook and Reconcile.
+func ValidateGameServer(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if profileName, profile := resolveProfile(gs, gameDef); profileName != "" && profile == nil {
+		var known []string
+		if gameDef.Spec.Profiles != nil {
+			for _, p := range gameDef.Spec.Profiles.Values {
+				known = append(known, p.Name)
+			}
+		}
+		errs = append(errs, field.NotSupported(specPath.Child("profile"), profileName, known))
+	}
+
+	names := make([]string, 0, len(gameDef.Inputs))
+	for name := range gameDef.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		input := gameDef.Inputs[name]
+		value, ok := gs.Spec.Inputs[name]
+		if !ok || !inputProvided(value) {
+			if input.Required {
+				errs = append(errs, field.Required(specPath.Child("inputs").Key(name),
+					fmt.Sprintf("input %q is required by GameDefinition %s", name, gameDef.Name)))
+			}
+			continue
+		}
+		if _, err := inputValue(input, value); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("inputs").Key(name), string(value.Raw), err.Error()))
+		}
+	}
+
+	if gs.Spec.VolumeSize != "" {
+		if _, err := resolveVolumeSize(gs, gameDef); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("volumeSize"), gs.Spec.VolumeSize, err.Error()))
+		}
+	}
+	if gs.Spec.Storage != nil {
+		for _, issue := range validateVolumeClaimOptions(specPath.Child("storage"), *gs.Spec.Storage) {
+			errs = append(errs, field.Invalid(issue.Field, field.OmitValueType{}, issue.Message))
+		}
+	}
+	if gs.Spec.Backup != nil {
+		for _, issue := range validateBackupSchedule(specPath.Child("backup"), gs.Spec.Backup) {
+			errs = append(errs, field.Invalid(issue.Field, field.OmitValueType{}, issue.Message))
+		}
+	}
+
+	for _, issue := range validateMods(specPath.Child("mods"), gs.Spec.Mods) {
+		errs = append(errs, field.Invalid(issue.Field, field.OmitValueType{}, issue.Message))
+	}
+	if len(gs.Spec.RemoveMods) > 0 {
+		known := gameDef.Spec.Mods
+		if _, profile := resolveProfile(gs, gameDef); profile != nil {
+			known = mergeMods(known, profile.Mods)
+		}
+		for i, name := range gs.Spec.RemoveMods {
+			if !slices.ContainsFunc(known, func(mod v1alpha1.Mod) bool { return mod.Name == name }) {
+				errs = append(errs, field.Invalid(specPath.Child("removeMods").Index(i), name,
+					fmt.Sprintf("GameDefinition %s has no mod %q for this GameServer", gameDef.Name, name)))
+			}
+		}
+	}
+
+	// Only try to render once the inputs are known to be complete, the error would just repeat the above.
+	if len(errs) == 0 {
+		resolved, err := resolveGameDefinitionSpec(gs, gameDef)
+		if err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("inputs"), field.OmitValueType{}, err.Error()))
+		} else {
+			rendered := gameDef.DeepCopy()
+			rendered.Spec = resolved
+			errs = append(errs, validateGameServerStorage(gs, rendered)...)
+			errs = append(errs, validateGameServerMods(gs, rendered)...)
+		}
+	}
+
+	return errs
+}
+
+// validateGameServerStorage rejects storage settings that only turn out impossible once the GameDefinition is
+// rendered for the GameServer, e.g. an input that switches storage off while the GameServer asks for a volumeSize.
+func validateGameServerStorage(gs *v1alpha1.GameServer, rendered *v1alpha1.GameDefinition) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	storage := storageConfig(gs, rendered)
+	if storage != nil && storage.Enabled.Type != v1alpha1.Bool {
+		errs = append(errs, field.Invalid(specPath.Child("inputs"), storage.Enabled.StrVal,
+			fmt.Sprintf("storage.enabled of GameDefinition %s must render to a boolean", rendered.Name)))
+		return errs
+	}
+	if gs.Spec.Storage != nil && !usesStorage(gs, rendered) {
+		errs = append(errs, field.Invalid(specPath.Child("storage"), field.OmitValueType{},
+			fmt.Sprintf("GameDefinition %s has no persistent storage for this GameServer, there is no claim to configure", rendered.Name)))
+	}
+	if gs.Spec.Backup != nil && !usesStorage(gs, rendered) {
+		errs = append(errs, field.Invalid(specPath.Child("backup"), field.OmitValueType{},
+			fmt.Sprintf("GameDefinition %s has no persistent storage for this GameServer, there is nothing to back up", rendered.Name)))
+	}
+	if gs.Spec.RestoreFrom != nil && !usesStorage(gs, rendered) {
+		errs = append(errs, field.Invalid(specPath.Child("restoreFrom"), gs.Spec.RestoreFrom.Backup,
+			fmt.Sprintf("GameDefinition %s has no persistent storage for this GameServer, there is nothing to restore into", rendered.Name)))
+	}
+	if schedule := backupSchedule(gs, rendered); schedule != nil && gs.Spec.Backup == nil && usesStorage(gs, rendered) {
+		if _, err := parseCronSchedule(schedule.Schedule); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("inputs"), schedule.Schedule,
+				fmt.Sprintf("storage.backup.schedule of GameDefinition %s must render to a valid schedule: %s", rendered.Name, err.Error())))
+		}
+	}
+	if seed := volumeClaimOptions(gs, rendered).Seed; seed != nil && (gs.Spec.Storage == nil || gs.Spec.Storage.Seed == nil) {
+		for _, issue := range validateSeed(field.NewPath("storage", "seed"), seed) {
+			errs = append(errs, field.Invalid(specPath.Child("inputs"), field.OmitValueType{},
+				fmt.Sprintf("GameDefinition %s must render to a valid seed: %s", rendered.Name, issue.Message)))
+		}
+	}
+	if gs.Spec.VolumeSize != "" && !usesStorage(gs, rendered) {
+		errs = append(errs, field.Invalid(specPath.Child("volumeSize"), gs.Spec.VolumeSize,
+			fmt.Sprintf("GameDefinition %s has no persistent storage for this GameServer, its data lives in an emptyDir", rendered.Name)))
+	}
+	if storage != nil && storage.SizeLimit != "" && !usesStorage(gs, rendered) {
+		if _, err := resource.ParseQuantity(storage.SizeLimit); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("volumeSize"), storage.SizeLimit,
+				fmt.Sprintf("storage sizeLimit of GameDefinition %s is not a valid quantity", rendered.Name)))
+		}
+	}
+	return errs
+}
+
+// ValidateGameServerUpdate rejects changes to a GameServer that its existing claim can't follow. Claims only
+// grow, so volumeSize can't shrink. The claim options can't change once the claim exists, that only warns
+// because the claim may not have been created yet.
+func ValidateGameServerUpdate(oldGs, gs *v1alpha1.GameServer) (field.ErrorList, []string) {
+	var errs field.ErrorList
+	var warnings []string
+
+	if oldGs.Spec.VolumeSize != "" && gs.Spec.VolumeSize != "" {
+		oldSize, oldErr := resource.ParseQuantity(oldGs.Spec.VolumeSize)
+		size, err := resource.ParseQuantity(gs.Spec.VolumeSize)
+		if oldErr == nil && err == nil && size.Cmp(oldSize) < 0 {
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "volumeSize"),
+				fmt.Sprintf("volumeSize can't shrink from %s to %s, volumes can only grow", oldGs.Spec.VolumeSize, gs.Spec.VolumeSize)))
+		}
+	}
+	if !equality.Semantic.DeepEqual(oldGs.Spec.Storage, gs.Spec.Storage) {
+		warnings = append(warnings, "spec.storage only applies when the claim is created, an existing claim keeps its storage class, access modes and volume mode")
+	}
+	return errs, warnings
+}
+
+// validateGameServerMods checks the mods the GameServer ends up with. Its own mods may clash with those of
+// the GameDefinition, e.g. by path, and placeholders may render to an invalid mod.
+func validateGameServerMods(gs *v1alpha1.GameServer, rendered *v1alpha1.GameDefinition) field.ErrorList {
+	var errs field.ErrorList
+	for _, issue := range validateMods(field.NewPath("mods"), resolveMods(gs, rendered)) {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "mods"), field.OmitValueType{},
+			fmt.Sprintf("the mods of GameDefinition %s and this GameServer don't add up: %s", rendered.Name, issue.Message)))
+	}
+	return errs
+}
@@ -0,0 +1,654 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+const (
+	// DefaultBackupImage archives the game data. It needs sh, tar, gzip and sha256sum.
+	DefaultBackupImage = "busybox:1.36"
+	// DefaultBackupS3Image uploads archives to S3 with the MinIO client.
+	DefaultBackupS3Image = "minio/mc:RELEASE.2024-11-21T17-21-54Z"
+
+	// backupArchiveFinalizer deletes the archive of a PVC or S3 backup together with the GameServerBackup.
+	// Scheduled backups carry it, so pruning them frees the space.
+	backupArchiveFinalizer = "kraftnetes.com/backup-archive"
+
+	defaultFlushDelay  = 5 * time.Second
+	backupPollInterval = 5 * time.Second
+	// backupJobDeadline bounds how long a copy Job may run.
+	backupJobDeadline int64 = 3600
+)
+
+// GameServerBackupReconciler reconciles a GameServerBackup object
+type GameServerBackupReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Executor PodExecutor
+	// Image and S3Image run the copy Jobs. SetupWithManager defaults them to DefaultBackupImage and
+	// DefaultBackupS3Image.
+	Image   string
+	S3Image string
+}
+
+// +kubebuilder:rbac:groups=kraftnetes.com,resources=gameserverbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=kraftnetes.com,resources=gameserverbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kraftnetes.com,resources=gameserverbackups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile takes a backup once: it asks the game to save, waits for the flush delay, then copies the game data
+// claim to the target with a Job, or takes a VolumeSnapshot of it. Completed and Failed backups are final.
+func (r *GameServerBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	backup := &v1alpha1.GameServerBackup{}
+	if err := r.Get(ctx, req.NamespacedName, backup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !backup.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, backup)
+	}
+	if backupFinished(backup) {
+		return ctrl.Result{}, nil
+	}
+
+	if err := validateBackupTarget(backup.Spec.Target); err != nil {
+		return ctrl.Result{}, r.fail(ctx, backup, "InvalidTarget", err.Error())
+	}
+
+	gs := &v1alpha1.GameServer{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.GameServer, Namespace: backup.Namespace}, gs); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.fail(ctx, backup, "GameServerNotFound", fmt.Sprintf("GameServer %s not found", backup.Spec.GameServer))
+		}
+		logger.Error(err, "Failed to get GameServer", "gameServer", backup.Spec.GameServer)
+		return ctrl.Result{}, err
+	}
+	id := ResolveGameServerId(gs)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("gs-%s-pvc", id), Namespace: gs.Namespace}, pvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.fail(ctx, backup, "NoStorage",
+				fmt.Sprintf("GameServer %s has no persistent storage to back up", gs.Name))
+		}
+		logger.Error(err, "Failed to get pvc")
+		return ctrl.Result{}, err
+	}
+
+	if backup.Status.Phase == "" {
+		backup.Status.Phase = v1alpha1.BackupPending
+		backup.Status.GameServerID = id
+		backup.Status.StartTime = metaNow()
+		if err := r.Status().Update(ctx, backup); err != nil {
+			logger.Error(err, "Failed to update GameServerBackup status")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(backup, corev1.EventTypeNormal, "BackupStarted", "Backing up GameServer %s", gs.Name)
+	}
+
+	pod, err := getGamePod(ctx, r.Client, gs)
+	if err != nil {
+		logger.Error(err, "Failed to get Pod")
+		return ctrl.Result{}, err
+	}
+
+	if backup.Status.Phase == v1alpha1.BackupPending {
+		if res, err := r.flush(ctx, backup, gs, pod); err != nil || !res.IsZero() {
+			return res, err
+		}
+	}
+	if backup.Status.Phase == v1alpha1.BackupFlushing {
+		if remaining := time.Until(backup.Status.FlushedAt.Add(r.flushDelay(ctx, gs))); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	if backup.Status.Phase != v1alpha1.BackupRunning {
+		backup.Status.Phase = v1alpha1.BackupRunning
+		backup.Status.Message = ""
+		if err := r.Status().Update(ctx, backup); err != nil {
+			logger.Error(err, "Failed to update GameServerBackup status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if backup.Spec.Target.VolumeSnapshot != nil {
+		return r.reconcileSnapshot(ctx, backup, pvc)
+	}
+	return r.reconcileCopyJob(ctx, backup, pvc, pod)
+}
+
+// flush asks the running game to save its data with the backup's saveCommand, or else the backupStrategy of its
+// GameDefinition. A game that isn't running has nothing to flush. Failing to flush only warns, the copy still
+// has everything the game wrote so far.
+func (r *GameServerBackupReconciler) flush(ctx context.Context, backup *v1alpha1.GameServerBackup, gs *v1alpha1.GameServer, pod *corev1.Pod) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	strategy := &v1alpha1.BackupStrategy{Stdin: backup.Spec.SaveCommand}
+	if strategy.Stdin == "" {
+		if mergedConfig, err := resolveMergedConfig(ctx, r.Client, gs); err == nil && mergedConfig.BackupStrategy != nil {
+			strategy = mergedConfig.BackupStrategy
+		}
+	}
+	if pod == nil || pod.Status.Phase != corev1.PodRunning || r.Executor == nil || (strategy.Stdin == "" && len(strategy.Cmd) == 0) {
+		return ctrl.Result{}, nil
+	}
+
+	var err error
+	if strategy.Stdin != "" {
+		err = r.Executor.SendStdin(ctx, pod, gameContainerName, strategy.Stdin)
+	} else {
+		err = r.Executor.Exec(ctx, pod, gameContainerName, strategy.Cmd)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to flush game")
+		r.Recorder.Eventf(backup, corev1.EventTypeWarning, "FlushFailed", "Failed to ask the game to save, backing up as is: %s", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	backup.Status.Phase = v1alpha1.BackupFlushing
+	backup.Status.FlushedAt = metaNow()
+	backup.Status.Message = "Waiting for the game to finish saving"
+	if err := r.Status().Update(ctx, backup); err != nil {
+		logger.Error(err, "Failed to update GameServerBackup status")
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(backup, corev1.EventTypeNormal, "GameFlushed", "Asked the game in Pod %s to save", pod.Name)
+	return ctrl.Result{RequeueAfter: r.flushDelay(ctx, gs)}, nil
+}
+
+// flushDelay is how long the game gets to save before the data is copied.
+func (r *GameServerBackupReconciler) flushDelay(ctx context.Context, gs *v1alpha1.GameServer) time.Duration {
+	mergedConfig, err := resolveMergedConfig(ctx, r.Client, gs)
+	if err != nil || mergedConfig.BackupStrategy == nil || mergedConfig.BackupStrategy.FlushDelay == "" {
+		return defaultFlushDelay
+	}
+	d, err := time.ParseDuration(mergedConfig.BackupStrategy.FlushDelay)
+	if err != nil || d < 0 {
+		return defaultFlushDelay
+	}
+	return d
+}
+
+// reconcileSnapshot takes a VolumeSnapshot of the game data claim, owned by the backup, and waits until it is
+// ready to use.
+func (r *GameServerBackupReconciler) reconcileSnapshot(ctx context.Context, backup *v1alpha1.GameServerBackup, pvc *corev1.PersistentVolumeClaim) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	snapshot := buildBackupSnapshot(backup, pvc)
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(volumeSnapshotGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: snapshot.GetName(), Namespace: snapshot.GetNamespace()}, existing)
+	if meta.IsNoMatchError(err) {
+		return ctrl.Result{}, r.fail(ctx, backup, "SnapshotUnavailable", "the VolumeSnapshot CRD is not installed")
+	}
+	if apierrors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(backup, snapshot, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, snapshot); err != nil {
+			logger.Error(err, "Failed to create VolumeSnapshot")
+			r.Recorder.Event(backup, corev1.EventTypeWarning, "SnapshotCreateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(backup, corev1.EventTypeNormal, "SnapshotCreated", "Created VolumeSnapshot %s of pvc %s", snapshot.GetName(), pvc.Name)
+		return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to get VolumeSnapshot")
+		return ctrl.Result{}, err
+	}
+
+	if message, _, _ := unstructured.NestedString(existing.Object, "status", "error", "message"); message != "" {
+		return ctrl.Result{}, r.fail(ctx, backup, "SnapshotFailed", fmt.Sprintf("VolumeSnapshot %s failed: %s", existing.GetName(), message))
+	}
+	if ready, _, _ := unstructured.NestedBool(existing.Object, "status", "readyToUse"); !ready {
+		return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+	}
+
+	var size int64
+	if restoreSize, ok, _ := unstructured.NestedString(existing.Object, "status", "restoreSize"); ok {
+		if quantity, err := resource.ParseQuantity(restoreSize); err == nil {
+			size = quantity.Value()
+		}
+	}
+	return ctrl.Result{}, r.complete(ctx, backup, existing.GetName(), size, "")
+}
+
+// reconcileCopyJob archives the game data claim to the PVC or S3 target with a Job and reads the size and
+// checksum from its termination message.
+func (r *GameServerBackupReconciler) reconcileCopyJob(ctx context.Context, backup *v1alpha1.GameServerBackup, pvc *corev1.PersistentVolumeClaim, gamePod *corev1.Pod) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: backupJobName(backup), Namespace: backup.Namespace}, job)
+	if apierrors.IsNotFound(err) {
+		// A ReadWriteOnce claim can only be mounted on the node the game runs on.
+		nodeName := ""
+		if gamePod != nil {
+			nodeName = gamePod.Spec.NodeName
+		}
+		job = buildBackupJob(backup, pvc.Name, nodeName, r.Image, r.S3Image)
+		if err := controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, job); err != nil {
+			logger.Error(err, "Failed to create backup Job")
+			r.Recorder.Event(backup, corev1.EventTypeWarning, "JobCreateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(backup, corev1.EventTypeNormal, "JobCreated", "Created Job %s to copy pvc %s", job.Name, pvc.Name)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to get backup Job")
+		return ctrl.Result{}, err
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return ctrl.Result{}, r.fail(ctx, backup, "JobFailed", fmt.Sprintf("Job %s failed: %s", job.Name, condition.Message))
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		logger.Error(err, "Failed to list backup Job Pods")
+		return ctrl.Result{}, err
+	}
+	result, err := backupJobResult(pods.Items)
+	if err != nil {
+		return ctrl.Result{}, r.fail(ctx, backup, "NoResult", fmt.Sprintf("Job %s succeeded but %s", job.Name, err.Error()))
+	}
+	return ctrl.Result{}, r.complete(ctx, backup, backupLocation(backup), result.SizeBytes, result.Checksum)
+}
+
+// reconcileDelete removes the archive of a completed PVC or S3 backup with a Job before letting the backup go.
+// If the Job fails the archive is left behind with a warning, a lost archive must not block the deletion.
+func (r *GameServerBackupReconciler) reconcileDelete(ctx context.Context, backup *v1alpha1.GameServerBackup) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(backup, backupArchiveFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if backup.Status.Phase != v1alpha1.BackupCompleted || backup.Spec.Target.VolumeSnapshot != nil {
+		return ctrl.Result{}, r.removeArchiveFinalizer(ctx, backup)
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: archiveDeleteJobName(backup), Namespace: backup.Namespace}, job)
+	if apierrors.IsNotFound(err) {
+		job = buildArchiveDeleteJob(backup, r.Image, r.S3Image)
+		if err := controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, job); err != nil {
+			logger.Error(err, "Failed to create archive delete Job")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to get archive delete Job")
+		return ctrl.Result{}, err
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			r.Recorder.Eventf(backup, corev1.EventTypeWarning, "ArchiveDeleteFailed", "Failed to delete %s, it is left behind: %s", backup.Status.Location, condition.Message)
+			return ctrl.Result{}, r.removeArchiveFinalizer(ctx, backup)
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, r.removeArchiveFinalizer(ctx, backup)
+}
+
+func (r *GameServerBackupReconciler) removeArchiveFinalizer(ctx context.Context, backup *v1alpha1.GameServerBackup) error {
+	controllerutil.RemoveFinalizer(backup, backupArchiveFinalizer)
+	if err := r.Update(ctx, backup); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to remove finalizer")
+		return err
+	}
+	return nil
+}
+
+// complete records a stored backup.
+func (r *GameServerBackupReconciler) complete(ctx context.Context, backup *v1alpha1.GameServerBackup, location string, size int64, checksum string) error {
+	backup.Status.Phase = v1alpha1.BackupCompleted
+	backup.Status.Message = ""
+	backup.Status.Location = location
+	backup.Status.SizeBytes = size
+	backup.Status.Checksum = checksum
+	backup.Status.CompletionTime = metaNow()
+	if err := r.Status().Update(ctx, backup); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServerBackup status")
+		return err
+	}
+	r.Recorder.Eventf(backup, corev1.EventTypeNormal, "BackupCompleted", "Stored %d bytes in %s", size, location)
+	return nil
+}
+
+// fail marks the backup as Failed. It isn't retried, create a new GameServerBackup instead.
+func (r *GameServerBackupReconciler) fail(ctx context.Context, backup *v1alpha1.GameServerBackup, reason, message string) error {
+	log.FromContext(ctx).Info("Backup failed", "reason", reason, "message", message)
+	backup.Status.Phase = v1alpha1.BackupFailed
+	backup.Status.Message = message
+	backup.Status.CompletionTime = metaNow()
+	if err := r.Status().Update(ctx, backup); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServerBackup status")
+		return err
+	}
+	r.Recorder.Event(backup, corev1.EventTypeWarning, reason, message)
+	return nil
+}
+
+// metaNow returns the current time for optional status fields.
+func metaNow() *metav1.Time {
+	now := metav1.Now()
+	return &now
+}
+
+// getGamePod returns the GameServer's Pod, or nil if it doesn't exist.
+func getGamePod(ctx context.Context, c client.Client, gs *v1alpha1.GameServer) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	err := c.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("gs-%s-pod", ResolveGameServerId(gs)), Namespace: gs.Namespace}, pod)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// validateBackupTarget checks that exactly one target is set.
+func validateBackupTarget(target v1alpha1.BackupTarget) error {
+	set := 0
+	if target.PVC != nil {
+		set++
+		if target.PVC.ClaimName == "" {
+			return fmt.Errorf("target.pvc.claimName is required")
+		}
+	}
+	if target.VolumeSnapshot != nil {
+		set++
+	}
+	if target.S3 != nil {
+		set++
+		if target.S3.Endpoint == "" || target.S3.Bucket == "" || target.S3.CredentialsSecret == "" {
+			return fmt.Errorf("target.s3 needs endpoint, bucket and credentialsSecret")
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of target.pvc, target.volumeSnapshot and target.s3 must be set")
+	}
+	return nil
+}
+
+// backupJobName is the name of the Job copying the backup.
+func backupJobName(backup *v1alpha1.GameServerBackup) string {
+	return backup.Name + "-backup"
+}
+
+// backupArchivePath is the path of the archive in the PVC or bucket: <path>/<namespace>/<kraftnetes-id>/<backup>.tar.gz.
+func backupArchivePath(backup *v1alpha1.GameServerBackup) string {
+	prefix := ""
+	switch {
+	case backup.Spec.Target.PVC != nil:
+		prefix = backup.Spec.Target.PVC.Path
+	case backup.Spec.Target.S3 != nil:
+		prefix = backup.Spec.Target.S3.Prefix
+	}
+	return strings.TrimPrefix(path.Join(prefix, backup.Namespace, backup.Status.GameServerID, backup.Name+".tar.gz"), "/")
+}
+
+// backupLocation describes where a copied backup is stored, as pvc://<claim>/<file> or s3://<bucket>/<key>.
+func backupLocation(backup *v1alpha1.GameServerBackup) string {
+	if s3 := backup.Spec.Target.S3; s3 != nil {
+		return fmt.Sprintf("s3://%s/%s", s3.Bucket, backupArchivePath(backup))
+	}
+	return fmt.Sprintf("pvc://%s/%s", backup.Spec.Target.PVC.ClaimName, backupArchivePath(backup))
+}
+
+// archiveScript tars /data into $ARCHIVE and writes its size and checksum as JSON to $RESULT. The archive is
+// renamed into place once complete, so a failed run never leaves a truncated backup behind.
+const archiveScript = `set -e
+mkdir -p "$(dirname "$ARCHIVE")"
+tar -czf "$ARCHIVE.partial" -C /data .
+mv "$ARCHIVE.partial" "$ARCHIVE"
+size=$(wc -c < "$ARCHIVE")
+sum=$(sha256sum "$ARCHIVE" | cut -d' ' -f1)
+printf '{"sizeBytes":%s,"checksum":"sha256:%s"}' $size "$sum" > "$RESULT"`
+
+// uploadScript copies the archive to the bucket and passes on the result of the archive step.
+const uploadScript = `set -e
+mc alias set target "$S3_ENDPOINT" "$AWS_ACCESS_KEY_ID" "$AWS_SECRET_ACCESS_KEY" $MC_FLAGS
+mc cp $MC_FLAGS /work/backup.tar.gz "target/$S3_BUCKET/$S3_KEY"
+cat /work/result.json > /dev/termination-log`
+
+// deleteArchiveScript removes the archive from the PVC target, or from the bucket if an S3 endpoint is set.
+const deleteArchiveScript = `set -e
+if [ -n "$S3_ENDPOINT" ]; then
+  mc alias set target "$S3_ENDPOINT" "$AWS_ACCESS_KEY_ID" "$AWS_SECRET_ACCESS_KEY" $MC_FLAGS
+  mc rm $MC_FLAGS "target/$S3_BUCKET/$S3_KEY"
+else
+  rm -f "$ARCHIVE"
+fi`
+
+// buildBackupJob returns the Job archiving the game data claim. For a PVC target the archive is written straight
+// to the target claim, for S3 an init container archives it into an emptyDir and the MinIO client uploads it.
+func buildBackupJob(backup *v1alpha1.GameServerBackup, claimName, nodeName, image, s3Image string) *batchv1.Job {
+	archive := corev1.Container{
+		Name:    "archive",
+		Image:   image,
+		Command: []string{"sh", "-c", archiveScript},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "game-data", MountPath: "/data", ReadOnly: true},
+		},
+	}
+	volumes := []corev1.Volume{{
+		Name: "game-data",
+		VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: claimName,
+			ReadOnly:  true,
+		}},
+	}}
+
+	podSpec := corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever, NodeName: nodeName}
+	if target := backup.Spec.Target.PVC; target != nil {
+		archive.Env = []corev1.EnvVar{
+			{Name: "ARCHIVE", Value: "/backup/" + backupArchivePath(backup)},
+			{Name: "RESULT", Value: "/dev/termination-log"},
+		}
+		archive.VolumeMounts = append(archive.VolumeMounts, corev1.VolumeMount{Name: "backup", MountPath: "/backup"})
+		volumes = append(volumes, corev1.Volume{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: target.ClaimName,
+			}},
+		})
+		podSpec.Containers = []corev1.Container{archive}
+	} else if backup.Spec.Target.S3 != nil {
+		archive.Env = []corev1.EnvVar{
+			{Name: "ARCHIVE", Value: "/work/backup.tar.gz"},
+			{Name: "RESULT", Value: "/work/result.json"},
+		}
+		archive.VolumeMounts = append(archive.VolumeMounts, corev1.VolumeMount{Name: "work", MountPath: "/work"})
+		volumes = append(volumes, corev1.Volume{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+
+		podSpec.InitContainers = []corev1.Container{archive}
+		podSpec.Containers = []corev1.Container{s3Container(backup, "upload", s3Image, uploadScript)}
+		podSpec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "work", MountPath: "/work"}}
+	}
+	podSpec.Volumes = volumes
+	return newBackupJob(backup, backupJobName(backup), podSpec)
+}
+
+// s3Container returns a MinIO client container running script against the S3 target.
+func s3Container(backup *v1alpha1.GameServerBackup, name, s3Image, script string) corev1.Container {
+	target := backup.Spec.Target.S3
+	mcFlags := ""
+	if target.Insecure {
+		mcFlags = "--insecure"
+	}
+	return corev1.Container{
+		Name:    name,
+		Image:   s3Image,
+		Command: []string{"sh", "-c", script},
+		Env: []corev1.EnvVar{
+			{Name: "S3_ENDPOINT", Value: target.Endpoint},
+			{Name: "S3_BUCKET", Value: target.Bucket},
+			{Name: "S3_KEY", Value: backupArchivePath(backup)},
+			{Name: "MC_FLAGS", Value: mcFlags},
+			// The MinIO client keeps its config in the home directory, which may not be writable.
+			{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+		},
+		EnvFrom: []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: target.CredentialsSecret}},
+		}},
+	}
+}
+
+// archiveDeleteJobName is the name of the Job deleting the backup's archive.
+func archiveDeleteJobName(backup *v1alpha1.GameServerBackup) string {
+	return backup.Name + "-delete"
+}
+
+// buildArchiveDeleteJob returns the Job removing the archive of a PVC or S3 backup.
+func buildArchiveDeleteJob(backup *v1alpha1.GameServerBackup, image, s3Image string) *batchv1.Job {
+	podSpec := corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever}
+	if target := backup.Spec.Target.PVC; target != nil {
+		podSpec.Containers = []corev1.Container{{
+			Name:         "delete",
+			Image:        image,
+			Command:      []string{"sh", "-c", deleteArchiveScript},
+			Env:          []corev1.EnvVar{{Name: "ARCHIVE", Value: "/backup/" + backupArchivePath(backup)}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "backup", MountPath: "/backup"}},
+		}}
+		podSpec.Volumes = []corev1.Volume{{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: target.ClaimName,
+			}},
+		}}
+	} else if backup.Spec.Target.S3 != nil {
+		podSpec.Containers = []corev1.Container{s3Container(backup, "delete", s3Image, deleteArchiveScript)}
+	}
+	return newBackupJob(backup, archiveDeleteJobName(backup), podSpec)
+}
+
+// newBackupJob wraps podSpec in a Job labelled with the backup's GameServer.
+func newBackupJob(backup *v1alpha1.GameServerBackup, name string, podSpec corev1.PodSpec) *batchv1.Job {
+	labels := map[string]string{
+		"app":             "gameserver-backup",
+		"gameserver":      backup.Spec.GameServer,
+		gameServerIdLabel: backup.Status.GameServerID,
+	}
+	backoffLimit, deadline := int32(2), backupJobDeadline
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: backup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// backupResult is what the copy Job reports in its termination message.
+type backupResult struct {
+	SizeBytes int64  `json:"sizeBytes"`
+	Checksum  string `json:"checksum"`
+}
+
+// backupJobResult reads the result from the termination message of the Job's succeeded Pod.
+func backupJobResult(pods []corev1.Pod) (backupResult, error) {
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.Message == "" {
+				continue
+			}
+			var result backupResult
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), &result); err != nil {
+				return backupResult{}, fmt.Errorf("its result %q can't be parsed: %w", status.State.Terminated.Message, err)
+			}
+			return result, nil
+		}
+	}
+	return backupResult{}, fmt.Errorf("reported no result")
+}
+
+// buildBackupSnapshot returns the VolumeSnapshot of the game data claim for a volumeSnapshot target.
+func buildBackupSnapshot(backup *v1alpha1.GameServerBackup, pvc *corev1.PersistentVolumeClaim) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(backup.Name)
+	snapshot.SetNamespace(backup.Namespace)
+	snapshot.SetLabels(map[string]string{
+		"app":             "gameserver-backup",
+		"gameserver":      backup.Spec.GameServer,
+		gameServerIdLabel: backup.Status.GameServerID,
+	})
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": pvc.Name},
+	}
+	if class := backup.Spec.Target.VolumeSnapshot.ClassName; class != "" {
+		spec["volumeSnapshotClassName"] = class
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GameServerBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor("gameserverbackup-controller")
+	if r.Image == "" {
+		r.Image = DefaultBackupImage
+	}
+	if r.S3Image == "" {
+		r.S3Image = DefaultBackupS3Image
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.GameServerBackup{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
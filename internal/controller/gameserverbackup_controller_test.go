@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("GameServerBackup", func() {
+	var backup *kraftnetescomv1alpha1.GameServerBackup
+
+	BeforeEach(func() {
+		backup = &kraftnetescomv1alpha1.GameServerBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "games"},
+			Spec: kraftnetescomv1alpha1.GameServerBackupSpec{
+				GameServer: "survival",
+				Target: kraftnetescomv1alpha1.BackupTarget{
+					PVC: &kraftnetescomv1alpha1.PVCBackupTarget{ClaimName: "backups", Path: "/worlds"},
+				},
+			},
+			Status: kraftnetescomv1alpha1.GameServerBackupStatus{GameServerID: "abc123"},
+		}
+	})
+
+	It("requires exactly one target", func() {
+		Expect(validateBackupTarget(backup.Spec.Target)).To(Succeed())
+		Expect(validateBackupTarget(kraftnetescomv1alpha1.BackupTarget{})).NotTo(Succeed())
+		backup.Spec.Target.VolumeSnapshot = &kraftnetescomv1alpha1.VolumeSnapshotBackupTarget{}
+		Expect(validateBackupTarget(backup.Spec.Target)).NotTo(Succeed())
+		Expect(validateBackupTarget(kraftnetescomv1alpha1.BackupTarget{S3: &kraftnetescomv1alpha1.S3BackupTarget{Bucket: "worlds"}})).NotTo(Succeed())
+	})
+
+	It("archives straight to a PVC target on the game's node", func() {
+		job := buildBackupJob(backup, "gs-abc123-pvc", "node-1", DefaultBackupImage, DefaultBackupS3Image)
+		Expect(job.Name).To(Equal("nightly-backup"))
+		Expect(job.Spec.Template.Spec.NodeName).To(Equal("node-1"))
+		Expect(job.Spec.Template.Spec.InitContainers).To(BeEmpty())
+
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "ARCHIVE", Value: "/backup/worlds/games/abc123/nightly.tar.gz"}))
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "RESULT", Value: "/dev/termination-log"}))
+		Expect(job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ReadOnly).To(BeTrue())
+		Expect(job.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+		Expect(backupLocation(backup)).To(Equal("pvc://backups/worlds/games/abc123/nightly.tar.gz"))
+	})
+
+	It("uploads to S3 after archiving into an emptyDir", func() {
+		backup.Spec.Target = kraftnetescomv1alpha1.BackupTarget{S3: &kraftnetescomv1alpha1.S3BackupTarget{
+			Endpoint:          "http://minio.minio:9000",
+			Bucket:            "worlds",
+			CredentialsSecret: "minio-credentials",
+			Insecure:          true,
+		}}
+		job := buildBackupJob(backup, "gs-abc123-pvc", "", DefaultBackupImage, DefaultBackupS3Image)
+		spec := job.Spec.Template.Spec
+		Expect(spec.InitContainers).To(HaveLen(1))
+		Expect(spec.InitContainers[0].Env).To(ContainElement(corev1.EnvVar{Name: "ARCHIVE", Value: "/work/backup.tar.gz"}))
+
+		upload := spec.Containers[0]
+		Expect(upload.Image).To(Equal(DefaultBackupS3Image))
+		Expect(upload.Env).To(ContainElements(
+			corev1.EnvVar{Name: "S3_KEY", Value: "games/abc123/nightly.tar.gz"},
+			corev1.EnvVar{Name: "MC_FLAGS", Value: "--insecure"},
+		))
+		Expect(upload.EnvFrom[0].SecretRef.Name).To(Equal("minio-credentials"))
+		Expect(backupLocation(backup)).To(Equal("s3://worlds/games/abc123/nightly.tar.gz"))
+	})
+
+	It("reads size and checksum from the termination message", func() {
+		pods := []corev1.Pod{
+			{Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+			{Status: corev1.PodStatus{
+				Phase: corev1.PodSucceeded,
+				ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: `{"sizeBytes":1048576,"checksum":"sha256:abcd"}`,
+				}}}},
+			}},
+		}
+		result, err := backupJobResult(pods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(backupResult{SizeBytes: 1048576, Checksum: "sha256:abcd"}))
+
+		_, err = backupJobResult(pods[:1])
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("reconciling", func() {
+		var (
+			ctx        = context.Background()
+			reconciler *GameServerBackupReconciler
+			request    = reconcile.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "games"}}
+		)
+
+		setup := func(objects ...client.Object) {
+			backup.Status = kraftnetescomv1alpha1.GameServerBackupStatus{}
+			gs := &kraftnetescomv1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{
+				Name:      "survival",
+				Namespace: "games",
+				Labels:    map[string]string{"kraftnetes-id": "abc123"},
+			}}
+			c, testScheme := newFakeClient(append(objects, backup, gs)...)
+			reconciler = &GameServerBackupReconciler{
+				Client:   c,
+				Scheme:   testScheme,
+				Recorder: record.NewFakeRecorder(20),
+				Image:    DefaultBackupImage,
+				S3Image:  DefaultBackupS3Image,
+			}
+		}
+
+		It("fails for a GameServer without storage", func() {
+			setup()
+			Expect(reconciler.Reconcile(ctx, request)).To(Equal(reconcile.Result{}))
+
+			Expect(reconciler.Get(ctx, request.NamespacedName, backup)).To(Succeed())
+			Expect(backup.Status.Phase).To(Equal(kraftnetescomv1alpha1.BackupFailed))
+			Expect(backup.Status.Message).To(ContainSubstring("no persistent storage"))
+		})
+
+		It("copies the claim with a Job and completes from its result", func() {
+			setup(&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "gs-abc123-pvc", Namespace: "games"}})
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, request.NamespacedName, backup)).To(Succeed())
+			Expect(backup.Status.Phase).To(Equal(kraftnetescomv1alpha1.BackupRunning))
+			Expect(backup.Status.GameServerID).To(Equal("abc123"))
+
+			job := &batchv1.Job{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "nightly-backup", Namespace: "games"}, job)).To(Succeed())
+			Expect(metav1.IsControlledBy(job, backup)).To(BeTrue())
+
+			job.Status.Succeeded = 1
+			Expect(reconciler.Status().Update(ctx, job)).To(Succeed())
+			Expect(reconciler.Create(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly-backup-x", Namespace: "games", Labels: map[string]string{"job-name": "nightly-backup"}},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodSucceeded,
+					ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Message: `{"sizeBytes":2048,"checksum":"sha256:beef"}`,
+					}}}},
+				},
+			})).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, request.NamespacedName, backup)).To(Succeed())
+			Expect(backup.Status.Phase).To(Equal(kraftnetescomv1alpha1.BackupCompleted))
+			Expect(backup.Status.SizeBytes).To(Equal(int64(2048)))
+			Expect(backup.Status.Checksum).To(Equal("sha256:beef"))
+			Expect(backup.Status.Location).To(Equal("pvc://backups/worlds/games/abc123/nightly.tar.gz"))
+			Expect(backup.Status.CompletionTime).NotTo(BeNil())
+		})
+
+		It("deletes the archive before letting a backup go", func() {
+			backup.Finalizers = []string{backupArchiveFinalizer}
+			setup()
+			Expect(reconciler.Get(ctx, request.NamespacedName, backup)).To(Succeed())
+			backup.Status.Phase = kraftnetescomv1alpha1.BackupCompleted
+			backup.Status.GameServerID = "abc123"
+			Expect(reconciler.Status().Update(ctx, backup)).To(Succeed())
+			Expect(reconciler.Delete(ctx, backup)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			job := &batchv1.Job{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "nightly-delete", Namespace: "games"}, job)).To(Succeed())
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "ARCHIVE", Value: "/backup/worlds/games/abc123/nightly.tar.gz"}))
+			Expect(reconciler.Get(ctx, request.NamespacedName, backup)).To(Succeed())
+
+			job.Status.Succeeded = 1
+			Expect(reconciler.Status().Update(ctx, job)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apierrors.IsNotFound(reconciler.Get(ctx, request.NamespacedName, backup))).To(BeTrue())
+		})
+	})
+})
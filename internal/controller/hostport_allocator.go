@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DefaultHostPortRange is the range host ports are allocated from unless configured otherwise. It stays
+	// clear of the default NodePort range 30000-32767, a host port must not collide with a NodePort Service.
+	DefaultHostPortRange = "20000-23332"
+	// hostPortReservationTTL bounds how long a handed out port is remembered without showing up in the
+	// status of its GameServer, e.g. because the GameServer was deleted right away.
+	hostPortReservationTTL = 5 * time.Minute
+)
+
+// errHostPortRangeExhausted is returned when every port of the range is taken.
+var errHostPortRangeExhausted = errors.New("host port range exhausted")
+
+// HostPortAllocator hands out host ports from a fixed range, unique across the cluster. Ports aren't tracked
+// per node because they're picked before the Pod is scheduled; the scheduler still keeps a Pod off nodes
+// where something else holds its host port.
+//
+// The allocations themselves live in GameServer status. The allocator only remembers the ports it handed
+// out until they show up in the informer cache, so two GameServers reconciled back to back can't get the
+// same port.
+type HostPortAllocator struct {
+	first, last int32
+	now         func() time.Time
+
+	mu       sync.Mutex
+	reserved map[int32]hostPortReservation
+}
+
+// hostPortReservation is a port handed out to a GameServer that isn't in its status yet.
+type hostPortReservation struct {
+	owner types.NamespacedName
+	at    time.Time
+}
+
+// NewHostPortAllocator returns an allocator for the ports from first to last, inclusive.
+func NewHostPortAllocator(first, last int32) (*HostPortAllocator, error) {
+	if first < 1 || last > 65535 || first > last {
+		return nil, fmt.Errorf("invalid host port range %d-%d", first, last)
+	}
+	return &HostPortAllocator{first: first, last: last, now: time.Now, reserved: map[int32]hostPortReservation{}}, nil
+}
+
+// ParseHostPortRange parses a range in the form "20000-23332".
+func ParseHostPortRange(s string) (int32, int32, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("host port range %q is not in the form <min>-<max>", s)
+	}
+	first, err := strconv.ParseInt(strings.TrimSpace(from), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("host port range %q: %w", s, err)
+	}
+	last, err := strconv.ParseInt(strings.TrimSpace(to), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("host port range %q: %w", s, err)
+	}
+	return int32(first), int32(last), nil
+}
+
+// Allocate returns a host port for each of names, in the same order. A port gs already holds in its status
+// is kept, unless it left the range or a GameServer created earlier holds it too. Otherwise the hint is
+// used if it is free, e.g. the port of a Pod created before allocations were recorded, and finally the first
+// free port starting at one derived from the GameServer id and port name.
+//
+// gameServers must contain every GameServer holding host ports in its status; it may include gs itself.
+func (a *HostPortAllocator) Allocate(gs *v1alpha1.GameServer, gameServers []v1alpha1.GameServer, names []string, hints map[string]int32) ([]v1alpha1.HostPortStatus, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: gs.Namespace, Name: gs.Name}
+	others := make([]*v1alpha1.GameServer, 0, len(gameServers))
+	for i := range gameServers {
+		if gameServers[i].Namespace != gs.Namespace || gameServers[i].Name != gs.Name {
+			others = append(others, &gameServers[i])
+		}
+	}
+
+	// Ports claimed by anyone else can't be newly allocated. Of two GameServers claiming the same port in
+	// their status the older one keeps it.
+	taken := map[int32]bool{}
+	contested := map[int32]bool{}
+	// GameServers with ports in their status have caught up with their reservations.
+	recorded := map[types.NamespacedName]bool{}
+	for _, other := range others {
+		for _, hp := range other.Status.HostPorts {
+			taken[hp.HostPort] = true
+			if allocatedBefore(other, gs) {
+				contested[hp.HostPort] = true
+			}
+			recorded[types.NamespacedName{Namespace: other.Namespace, Name: other.Name}] = true
+		}
+	}
+	now := a.now()
+	for port, reservation := range a.reserved {
+		if reservation.owner == key || recorded[reservation.owner] || now.Sub(reservation.at) > hostPortReservationTTL {
+			delete(a.reserved, port)
+			continue
+		}
+		taken[port] = true
+		contested[port] = true
+	}
+
+	current := map[string]int32{}
+	for _, hp := range gs.Status.HostPorts {
+		current[hp.Name] = hp.HostPort
+	}
+
+	allocated := make([]v1alpha1.HostPortStatus, 0, len(names))
+	mine := map[int32]bool{}
+	free := func(port int32) bool {
+		return port >= a.first && port <= a.last && !mine[port]
+	}
+	for _, name := range names {
+		port, ok := current[name]
+		if !ok || !free(port) || contested[port] {
+			port = 0
+			if hint, ok := hints[name]; ok && free(hint) && !taken[hint] {
+				port = hint
+			}
+		}
+		if port == 0 {
+			size := int64(a.last-a.first) + 1
+			start := int64(preferredHostPortOffset(gs, name) % uint32(size))
+			for i := int64(0); i < size; i++ {
+				candidate := a.first + int32((start+i)%size)
+				if free(candidate) && !taken[candidate] {
+					port = candidate
+					break
+				}
+			}
+		}
+		if port == 0 {
+			return nil, fmt.Errorf("%w: no free port between %d and %d for port %q", errHostPortRangeExhausted, a.first, a.last, name)
+		}
+		mine[port] = true
+		allocated = append(allocated, v1alpha1.HostPortStatus{Name: name, HostPort: port})
+	}
+
+	for port := range mine {
+		a.reserved[port] = hostPortReservation{owner: key, at: now}
+	}
+	return allocated, nil
+}
+
+// allocatedBefore reports whether a was created before b, breaking ties by namespace and name.
+func allocatedBefore(a, b *v1alpha1.GameServer) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// allocatedHostPort returns the host port recorded in the GameServer status for a port, or 0 if it has none.
+func allocatedHostPort(gs *v1alpha1.GameServer, portName string) int32 {
+	for _, hp := range gs.Status.HostPorts {
+		if hp.Name == portName {
+			return hp.HostPort
+		}
+	}
+	return 0
+}
+
+// hostPortNames returns the names of the ports that need a host port.
+func hostPortNames(ports []v1alpha1.GamePort) []string {
+	var names []string
+	for _, gp := range ports {
+		if gp.Type == portTypeHostPort {
+			names = append(names, gp.Name)
+		}
+	}
+	return names
+}
+
+// preferredHostPortOffset derives the first port to try from the GameServer id and port name, so a
+// GameServer gets the same port whenever it is free.
+func preferredHostPortOffset(gs *v1alpha1.GameServer, portName string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(ResolveGameServerId(gs) + "/" + portName))
+	return h.Sum32()
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Host port allocation", func() {
+	var allocator *HostPortAllocator
+
+	gameServer := func(name string, age time.Duration, ports ...kraftnetescomv1alpha1.HostPortStatus) kraftnetescomv1alpha1.GameServer {
+		return kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "games",
+				CreationTimestamp: metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(-age)),
+			},
+			Status: kraftnetescomv1alpha1.GameServerStatus{HostPorts: ports},
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		allocator, err = NewHostPortAllocator(30000, 30009)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("parses the configured range", func() {
+		first, last, err := ParseHostPortRange(DefaultHostPortRange)
+		Expect(err).NotTo(HaveOccurred())
+		Expect([]int32{first, last}).To(Equal([]int32{20000, 23332}))
+		_, _, err = ParseHostPortRange("30000")
+		Expect(err).To(HaveOccurred())
+		_, err = NewHostPortAllocator(40000, 30000)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("gives a GameServer the same port every time", func() {
+		gs := gameServer("survival", 0)
+		first, err := allocator.Allocate(&gs, nil, []string{"minecraft"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		again, err := NewHostPortAllocator(30000, 30009)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again.Allocate(&gs, nil, []string{"minecraft"}, nil)).To(Equal(first))
+	})
+
+	It("keeps the port recorded in status", func() {
+		gs := gameServer("survival", 0, kraftnetescomv1alpha1.HostPortStatus{Name: "minecraft", HostPort: 30007})
+		Expect(allocator.Allocate(&gs, nil, []string{"minecraft"}, nil)).To(Equal(gs.Status.HostPorts))
+	})
+
+	It("never hands out a port another GameServer holds", func() {
+		var others []kraftnetescomv1alpha1.GameServer
+		for i := 0; i < 9; i++ {
+			others = append(others, gameServer(string(rune('a'+i)), time.Hour,
+				kraftnetescomv1alpha1.HostPortStatus{Name: "minecraft", HostPort: 30000 + int32(i)}))
+		}
+		gs := gameServer("survival", 0)
+		Expect(allocator.Allocate(&gs, others, []string{"minecraft"}, nil)).To(Equal(
+			[]kraftnetescomv1alpha1.HostPortStatus{{Name: "minecraft", HostPort: 30009}}))
+	})
+
+	It("remembers ports until they show up in status", func() {
+		a := gameServer("a", 0)
+		b := gameServer("b", 0)
+		all := []kraftnetescomv1alpha1.GameServer{a, b}
+		first, err := allocator.Allocate(&a, all, []string{"minecraft", "query"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := allocator.Allocate(&b, all, []string{"minecraft", "query"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		for _, hp := range second {
+			Expect(hp.HostPort).NotTo(BeElementOf(first[0].HostPort, first[1].HostPort))
+		}
+	})
+
+	It("forgets reservations once they are in status or have expired", func() {
+		a := gameServer("a", 0)
+		b := gameServer("b", 0)
+		first, err := allocator.Allocate(&a, nil, []string{"minecraft"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocator.reserved).To(HaveKey(first[0].HostPort))
+
+		a.Status.HostPorts = first
+		_, err = allocator.Allocate(&b, []kraftnetescomv1alpha1.GameServer{a}, []string{"minecraft"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocator.reserved).NotTo(HaveKey(first[0].HostPort))
+
+		// a is deleted before its status reaches the cache.
+		a.Status.HostPorts = nil
+		first, err = allocator.Allocate(&a, nil, []string{"query"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		later := time.Now().Add(hostPortReservationTTL + time.Second)
+		allocator.now = func() time.Time { return later }
+		_, err = allocator.Allocate(&b, nil, []string{"minecraft"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocator.reserved).NotTo(HaveKey(first[0].HostPort))
+	})
+
+	It("lets the older GameServer keep a contested port", func() {
+		port := kraftnetescomv1alpha1.HostPortStatus{Name: "minecraft", HostPort: 30003}
+		older := gameServer("older", time.Hour, port)
+		newer := gameServer("newer", 0, port)
+		all := []kraftnetescomv1alpha1.GameServer{older, newer}
+
+		Expect(allocator.Allocate(&older, all, []string{"minecraft"}, nil)).To(ConsistOf(port))
+		Expect(allocator.Allocate(&newer, all, []string{"minecraft"}, nil)).NotTo(ConsistOf(port))
+	})
+
+	It("adopts the port of an existing Pod if it is free", func() {
+		gs := gameServer("survival", 0)
+		Expect(allocator.Allocate(&gs, nil, []string{"minecraft"}, map[string]int32{"minecraft": 30005})).To(Equal(
+			[]kraftnetescomv1alpha1.HostPortStatus{{Name: "minecraft", HostPort: 30005}}))
+	})
+
+	It("reports an exhausted range", func() {
+		gs := gameServer("survival", 0)
+		names := []string{"p0", "p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8", "p9", "p10"}
+		_, err := allocator.Allocate(&gs, nil, names, nil)
+		Expect(err).To(MatchError(errHostPortRangeExhausted))
+	})
+})
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// hostPortRetryInterval is how often a GameServer waiting for a free host port tries again.
+const hostPortRetryInterval = time.Minute
+
+// reconcileHostPorts allocates a host port to every HostPort port of the GameServer and records them in its
+// status. It runs before the GameDefinition spec is rendered, so ${ports.<name>.hostPort} resolves to the
+// ports the Pod gets. When the range is exhausted the GameServer stays Pending and retries periodically.
+func (r *GameServerReconciler) reconcileHostPorts(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	names := hostPortNames(mergedConfig.Ports)
+	if len(names) == 0 && len(gs.Status.HostPorts) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	gameServers := &v1alpha1.GameServerList{}
+	if err := r.List(ctx, gameServers, client.MatchingFields{gameServerHostPortsIndex: "true"}); err != nil {
+		logger.Error(err, "Failed to list GameServers")
+		return ctrl.Result{}, err
+	}
+
+	// Pods created before host ports were recorded keep their port if it is still free.
+	var hints map[string]int32
+	pod, err := r.getExistingPod(ctx, fmt.Sprintf("gs-%s-pod", ResolveGameServerId(gs)), gs.Namespace)
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to get Pod")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodLookupFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+	if pod != nil {
+		hints = podHostPorts(pod)
+	}
+
+	allocated, err := r.HostPorts.Allocate(gs, gameServers.Items, names, hints)
+	if errors.Is(err, errHostPortRangeExhausted) {
+		logger.Info("No free host port", "error", err.Error())
+		meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+			Type:               v1alpha1.ConditionPortsAllocated,
+			Status:             metav1.ConditionFalse,
+			Reason:             "PortRangeExhausted",
+			Message:            err.Error(),
+			ObservedGeneration: gs.Generation,
+		})
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PortRangeExhausted", err.Error())
+		if err := r.setState(ctx, gs, v1alpha1.GameServerPending, err.Error()); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: hostPortRetryInterval}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(allocated) == 0 {
+		allocated = nil
+	}
+
+	changed := meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionPortsAllocated,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Allocated",
+		Message:            "Every HostPort port has a host port",
+		ObservedGeneration: gs.Generation,
+	})
+	portsChanged := !reflect.DeepEqual(gs.Status.HostPorts, allocated)
+	if !changed && !portsChanged {
+		return ctrl.Result{}, nil
+	}
+
+	gs.Status.HostPorts = allocated
+	if err := r.Status().Update(ctx, gs); err != nil {
+		logger.Error(err, "Failed to update GameServer status")
+		return ctrl.Result{}, err
+	}
+	if portsChanged && len(allocated) > 0 {
+		ports := make([]string, 0, len(allocated))
+		for _, hp := range allocated {
+			ports = append(ports, fmt.Sprintf("%s=%d", hp.Name, hp.HostPort))
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "HostPortsAllocated", "Allocated host ports %s", strings.Join(ports, ", "))
+		logger.Info("Allocated host ports", "ports", ports)
+	}
+	return ctrl.Result{}, nil
+}
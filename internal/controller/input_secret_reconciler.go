@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// generatedSecretLength is the number of characters of a generated secret input value.
+	generatedSecretLength = 32
+	generatedSecretChars  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// reconcileInputSecret makes sure the GameServer's input Secret holds a value for every secret input it
+// generates. Existing values are never replaced, so passwords stay stable across Pod recreations.
+func (r *GameServerReconciler) reconcileInputSecret(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	names := generatedSecretInputs(gs, gameDef)
+	if len(names) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = ""
+	}
+	labels := map[string]string{
+		"app":        "gameserver",
+		"gameserver": gs.Name,
+	}
+	return ctrl.Result{}, r.ensureSecret(ctx, gs, inputSecretName(gs), labels, values)
+}
+
+// ensureSecret makes sure the GameServer owns a Secret with every key of values. Missing keys are set to their
+// value, or a random one if it is empty; existing keys are left alone.
+func (r *GameServerReconciler) ensureSecret(ctx context.Context, gs *v1alpha1.GameServer, secretName string, labels map[string]string, values map[string]string) error {
+	logger := log.FromContext(ctx)
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: gs.Namespace}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to get Secret", "secret", secretName)
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "SecretLookupFailed", err.Error())
+		return err
+	}
+	create := err != nil
+	if create {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: gs.Namespace,
+				Labels:    labels,
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		if err := controllerutil.SetControllerReference(gs, secret, r.Scheme); err != nil {
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var generated []string
+	for _, key := range keys {
+		if _, ok := secret.Data[key]; ok {
+			continue
+		}
+		value := values[key]
+		if value == "" {
+			if value, err = generateSecretValue(generatedSecretLength); err != nil {
+				return err
+			}
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key] = []byte(value)
+		generated = append(generated, key)
+	}
+	if len(generated) == 0 {
+		return nil
+	}
+
+	if create {
+		err = r.Create(ctx, secret)
+	} else {
+		err = r.Update(ctx, secret)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to write Secret", "secret", secretName)
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "SecretWriteFailed", err.Error())
+		return err
+	}
+
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "SecretGenerated", "Generated %s in Secret %s", strings.Join(generated, ", "), secret.Name)
+	logger.Info("Generated Secret values", "secret", secret.Name, "keys", generated)
+	return nil
+}
+
+// generateSecretValue returns a random alphanumeric string of the given length.
+func generateSecretValue(length int) (string, error) {
+	charCount := big.NewInt(int64(len(generatedSecretChars)))
+	var value strings.Builder
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, charCount)
+		if err != nil {
+			return "", err
+		}
+		value.WriteByte(generatedSecretChars[n.Int64()])
+	}
+	return value.String(), nil
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// secretInputType is the input type for passwords and tokens. Its value is a reference to a Secret key, either
+// given by the GameServer through valueFrom or generated into the GameServer's input Secret.
+const secretInputType = "secret"
+
+// inputValues returns the value of every declared input that is set on the GameServer or has a default,
+// converted to the input's declared type (string, json.Number, bool or corev1.SecretKeySelector for secrets)
+// and checked against its constraints.
+func inputValues(gs *v1alpha1.GameServer, defInputs map[string]v1alpha1.GameDefinitionInput) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(defInputs))
+	for name, input := range defInputs {
+		raw, ok := gs.Spec.Inputs[name]
+		if !ok || !inputProvided(raw) {
+			if input.Type == secretInputType {
+				if !input.Required {
+					values[name] = corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: inputSecretName(gs)},
+						Key:                  name,
+					}
+				}
+				continue
+			}
+			if raw, ok = inputDefault(input); !ok {
+				continue
+			}
+		}
+		value, err := inputValue(input, raw)
+		if err != nil {
+			return nil, fmt.Errorf("input %q: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// inputSecretName returns the name of the Secret holding the generated values of the GameServer's secret inputs.
+func inputSecretName(gs *v1alpha1.GameServer) string {
+	return fmt.Sprintf("gs-%s-inputs", ResolveGameServerId(gs))
+}
+
+// generatedSecretInputs returns the sorted names of the secret inputs the operator generates a value for,
+// which are those that are neither required nor set on the GameServer.
+func generatedSecretInputs(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) []string {
+	var names []string
+	for name, input := range gameDef.Inputs {
+		if input.Type != secretInputType || input.Required {
+			continue
+		}
+		if raw, ok := gs.Spec.Inputs[name]; ok && inputProvided(raw) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inputProvided reports whether a GameServer input holds a value; null and "" count as not set.
+func inputProvided(raw apiextensionsv1.JSON) bool {
+	value := string(bytes.TrimSpace(raw.Raw))
+	return value != "" && value != "null" && value != `""`
+}
+
+// inputValue decodes a JSON input value, checks that it is of the input's type and satisfies its constraints.
+func inputValue(input v1alpha1.GameDefinitionInput, raw apiextensionsv1.JSON) (interface{}, error) {
+	value, err := typedInputValue(input.Type, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInputConstraints(input, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// secretInputSource is the value of a secret input on a GameServer.
+type secretInputSource struct {
+	ValueFrom *struct {
+		SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef"`
+	} `json:"valueFrom"`
+}
+
+// typedInputValue decodes a JSON input value, which must be of inputType. Numbers keep their literal text.
+func typedInputValue(inputType string, raw apiextensionsv1.JSON) (interface{}, error) {
+	if inputType == secretInputType {
+		var source secretInputSource
+		decoder := json.NewDecoder(bytes.NewReader(raw.Raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&source); err != nil || source.ValueFrom == nil || source.ValueFrom.SecretKeyRef == nil ||
+			source.ValueFrom.SecretKeyRef.Name == "" || source.ValueFrom.SecretKeyRef.Key == "" {
+			return nil, fmt.Errorf("secret inputs must be set as {valueFrom: {secretKeyRef: {name, key}}} or left out to generate a value")
+		}
+		return *source.ValueFrom.SecretKeyRef, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw.Raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+
+	switch inputType {
+	case "number":
+		if v, ok := value.(json.Number); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s is not a number", raw.Raw)
+	case "boolean":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s is not a boolean", raw.Raw)
+	default:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s is not a string, quote it", raw.Raw)
+	}
+}
+
+// checkInputConstraints checks a typed input value against the input's enum, minimum/maximum, pattern
+// and minLength/maxLength.
+func checkInputConstraints(input v1alpha1.GameDefinitionInput, value interface{}) error {
+	if len(input.Enum) > 0 {
+		allowed := make([]string, 0, len(input.Enum))
+		found := false
+		for _, option := range input.Enum {
+			if equalInputValues(option, value) {
+				found = true
+				break
+			}
+			allowed = append(allowed, strconv.Quote(option.String()))
+		}
+		if !found {
+			return fmt.Errorf("%q is not one of %s", formatTemplateValue(value), strings.Join(allowed, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("%s is not a number", v)
+		}
+		if input.Minimum != nil {
+			if minimum, err := anyValNumber(*input.Minimum); err == nil && n < minimum {
+				return fmt.Errorf("%s is less than the minimum %s", v, input.Minimum.String())
+			}
+		}
+		if input.Maximum != nil {
+			if maximum, err := anyValNumber(*input.Maximum); err == nil && n > maximum {
+				return fmt.Errorf("%s is greater than the maximum %s", v, input.Maximum.String())
+			}
+		}
+	case string:
+		length := int64(utf8.RuneCountInString(v))
+		if input.MinLength != nil && length < *input.MinLength {
+			return fmt.Errorf("%q is shorter than %d characters", v, *input.MinLength)
+		}
+		if input.MaxLength != nil && length > *input.MaxLength {
+			return fmt.Errorf("%q is longer than %d characters", v, *input.MaxLength)
+		}
+		if input.Pattern != "" {
+			pattern, err := regexp.Compile(input.Pattern)
+			if err != nil {
+				return fmt.Errorf("pattern %q is invalid: %w", input.Pattern, err)
+			}
+			if !pattern.MatchString(v) {
+				return fmt.Errorf("%q doesn't match the pattern %q", v, input.Pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// equalInputValues reports whether an enum option equals a typed input value.
+func equalInputValues(option v1alpha1.AnyVal, value interface{}) bool {
+	switch v := value.(type) {
+	case json.Number:
+		want, err := anyValNumber(option)
+		if err != nil {
+			return false
+		}
+		got, err := v.Float64()
+		return err == nil && got == want
+	case bool:
+		switch option.Type {
+		case v1alpha1.AnyValBool:
+			return option.BoolVal == v
+		case v1alpha1.AnyValString:
+			b, err := strconv.ParseBool(option.StrVal)
+			return err == nil && b == v
+		}
+		return false
+	default:
+		return option.String() == formatTemplateValue(value)
+	}
+}
+
+// anyValNumber returns the numeric value of a number or numeric string.
+func anyValNumber(value v1alpha1.AnyVal) (float64, error) {
+	switch value.Type {
+	case v1alpha1.AnyValNumber:
+		return value.NumVal, nil
+	case v1alpha1.AnyValString:
+		return strconv.ParseFloat(value.StrVal, 64)
+	default:
+		return 0, fmt.Errorf("%s is not a number", value.String())
+	}
+}
+
+// validateInputSchema checks that the constraints of an input fit its type and each other.
+func validateInputSchema(input v1alpha1.GameDefinitionInput) error {
+	isNumber := input.Type == "number"
+	isString := input.Type == "" || input.Type == "string"
+
+	if input.Type == secretInputType && len(input.Enum) > 0 {
+		return fmt.Errorf("enum doesn't apply to secret inputs")
+	}
+
+	if (input.Minimum != nil || input.Maximum != nil) && !isNumber {
+		return fmt.Errorf("minimum and maximum only apply to number inputs")
+	}
+	if (input.Pattern != "" || input.MinLength != nil || input.MaxLength != nil) && !isString {
+		return fmt.Errorf("pattern, minLength and maxLength only apply to string inputs")
+	}
+
+	var minimum, maximum float64
+	var err error
+	if input.Minimum != nil {
+		if minimum, err = anyValNumber(*input.Minimum); err != nil {
+			return fmt.Errorf("minimum: %w", err)
+		}
+	}
+	if input.Maximum != nil {
+		if maximum, err = anyValNumber(*input.Maximum); err != nil {
+			return fmt.Errorf("maximum: %w", err)
+		}
+	}
+	if input.Minimum != nil && input.Maximum != nil && minimum > maximum {
+		return fmt.Errorf("minimum %s is greater than maximum %s", input.Minimum.String(), input.Maximum.String())
+	}
+	if input.MinLength != nil && input.MaxLength != nil && *input.MinLength > *input.MaxLength {
+		return fmt.Errorf("minLength %d is greater than maxLength %d", *input.MinLength, *input.MaxLength)
+	}
+	if input.Pattern != "" {
+		if _, err := regexp.Compile(input.Pattern); err != nil {
+			return fmt.Errorf("pattern %q is invalid: %w", input.Pattern, err)
+		}
+	}
+
+	for _, option := range input.Enum {
+		raw, ok := inputDefault(v1alpha1.GameDefinitionInput{Type: input.Type, Default: option})
+		if !ok {
+			return fmt.Errorf("enum value %q is not a valid %s", option.String(), input.Type)
+		}
+		constraints := input
+		constraints.Enum = nil
+		if _, err := inputValue(constraints, raw); err != nil {
+			return fmt.Errorf("enum value %q: %w", option.String(), err)
+		}
+	}
+	return nil
+}
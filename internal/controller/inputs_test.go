@@ -0,0 +1,96 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Input validation", func() {
+	number := func(n float64) *kraftnetescomv1alpha1.AnyVal {
+		return &kraftnetescomv1alpha1.AnyVal{Type: kraftnetescomv1alpha1.AnyValNumber, NumVal: n}
+	}
+	length := func(n int64) *int64 { return &n }
+	raw := func(s string) apiextensionsv1.JSON { return apiextensionsv1.JSON{Raw: []byte(s)} }
+
+	It("checks values against their declared type", func() {
+		Expect(inputValue(kraftnetescomv1alpha1.GameDefinitionInput{Type: "string"}, raw(`1.2`))).Error().To(HaveOccurred())
+		Expect(inputValue(kraftnetescomv1alpha1.GameDefinitionInput{Type: "number"}, raw(`"20"`))).Error().To(HaveOccurred())
+		Expect(inputValue(kraftnetescomv1alpha1.GameDefinitionInput{Type: "boolean"}, raw(`"yes"`))).Error().To(HaveOccurred())
+		Expect(inputValue(kraftnetescomv1alpha1.GameDefinitionInput{Type: "boolean"}, raw(`false`))).To(BeFalse())
+	})
+
+	It("checks enum options", func() {
+		input := kraftnetescomv1alpha1.GameDefinitionInput{
+			Type: "string",
+			Enum: []kraftnetescomv1alpha1.AnyVal{{StrVal: "1.20.1"}, {StrVal: "1.21.5"}},
+		}
+		Expect(inputValue(input, raw(`"1.21.5"`))).To(Equal("1.21.5"))
+		Expect(inputValue(input, raw(`"1.19"`))).Error().To(MatchError(ContainSubstring(`is not one of "1.20.1", "1.21.5"`)))
+	})
+
+	It("checks minimum and maximum", func() {
+		input := kraftnetescomv1alpha1.GameDefinitionInput{Type: "number", Minimum: number(1), Maximum: number(100)}
+		Expect(inputValue(input, raw(`20`))).Error().NotTo(HaveOccurred())
+		Expect(inputValue(input, raw(`0`))).Error().To(MatchError(ContainSubstring("less than the minimum 1")))
+		Expect(inputValue(input, raw(`500`))).Error().To(MatchError(ContainSubstring("greater than the maximum 100")))
+	})
+
+	It("checks pattern and length", func() {
+		input := kraftnetescomv1alpha1.GameDefinitionInput{Type: "string", Pattern: "^[a-z]+$", MinLength: length(3), MaxLength: length(8)}
+		Expect(inputValue(input, raw(`"world"`))).Error().NotTo(HaveOccurred())
+		Expect(inputValue(input, raw(`"World"`))).Error().To(MatchError(ContainSubstring("doesn't match the pattern")))
+		Expect(inputValue(input, raw(`"ab"`))).Error().To(MatchError(ContainSubstring("shorter than 3")))
+		Expect(inputValue(input, raw(`"abcdefghi"`))).Error().To(MatchError(ContainSubstring("longer than 8")))
+	})
+
+	It("rejects constraints that don't fit the type or each other", func() {
+		Expect(validateInputSchema(kraftnetescomv1alpha1.GameDefinitionInput{Type: "string", Minimum: number(1)})).NotTo(Succeed())
+		Expect(validateInputSchema(kraftnetescomv1alpha1.GameDefinitionInput{Type: "number", Minimum: number(10), Maximum: number(1)})).NotTo(Succeed())
+		Expect(validateInputSchema(kraftnetescomv1alpha1.GameDefinitionInput{Type: "string", Pattern: "("})).NotTo(Succeed())
+		Expect(validateInputSchema(kraftnetescomv1alpha1.GameDefinitionInput{
+			Type: "number", Maximum: number(10), Enum: []kraftnetescomv1alpha1.AnyVal{*number(5), *number(20)},
+		})).NotTo(Succeed())
+	})
+
+	It("names the offending input in the GameServer validation errors", func() {
+		gameDef := &kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Inputs: map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+				"maxPlayers": {Type: "number", Maximum: number(100)},
+			},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{Game: "minecraft", Image: "itzg/minecraft-server"},
+		}
+		gs := &kraftnetescomv1alpha1.GameServer{Spec: kraftnetescomv1alpha1.GameServerSpec{
+			Game:   "minecraft",
+			Inputs: map[string]apiextensionsv1.JSON{"maxPlayers": raw(`500`)},
+		}}
+		errs := ValidateGameServer(gs, gameDef)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.inputs[maxPlayers]"))
+	})
+
+	It("rejects a volumeSize when the rendered definition has no persistent storage", func() {
+		gameDef := &kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Inputs: map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+				"persist": {Type: "boolean", Default: kraftnetescomv1alpha1.AnyVal{Type: kraftnetescomv1alpha1.AnyValBool, BoolVal: true}},
+			},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:    "minecraft",
+				Image:   "itzg/minecraft-server",
+				Storage: &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromString("${persist}")},
+			},
+		}
+		gs := &kraftnetescomv1alpha1.GameServer{Spec: kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft", VolumeSize: "5Gi"}}
+		Expect(ValidateGameServer(gs, gameDef)).To(BeEmpty())
+
+		gs.Spec.Inputs = map[string]apiextensionsv1.JSON{"persist": raw(`false`)}
+		errs := ValidateGameServer(gs, gameDef)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.volumeSize"))
+	})
+})
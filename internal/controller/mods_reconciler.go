@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	modsContainerName = "mods"
+	// modsChecksumMismatch starts the termination message of a mods container that refused a download.
+	modsChecksumMismatch = "checksum mismatch"
+)
+
+// modsScript syncs the mods listed in MODS, one "<name> <sha256> <path> <url>" line each, into the game data.
+// The paths it manages are kept in a manifest, files of mods that are no longer listed are removed and
+// everything else in the game data is left alone. Files that already match their checksum aren't downloaded
+// again.
+const modsScript = `set -e
+manifest=/data/.kraftnetes-mods
+touch "$manifest"
+wanted=$(printf '%s\n' "$MODS" | awk 'NF { print $3 }')
+while read -r path; do
+  if [ -n "$path" ] && ! printf '%s\n' "$wanted" | grep -qxF "$path"; then
+    rm -f "/data/$path"
+    echo "Removed $path"
+  fi
+done < "$manifest"
+printf '%s\n' "$MODS" | while read -r name sha256 path url; do
+  [ -n "$name" ] || continue
+  target="/data/$path"
+  if [ -f "$target" ] && [ "$(sha256sum "$target" | cut -d' ' -f1)" = "$sha256" ]; then
+    continue
+  fi
+  mkdir -p "$(dirname "$target")"
+  wget -q -O "$target.download" "$url"
+  actual=$(sha256sum "$target.download" | cut -d' ' -f1)
+  if [ "$actual" != "$sha256" ]; then
+    rm -f "$target.download"
+    echo "checksum mismatch for mod $name from $url: expected $sha256, got $actual" | tee /dev/termination-log
+    exit 1
+  fi
+  mv "$target.download" "$target"
+  echo "Installed $name"
+done
+printf '%s\n' "$wanted" > "$manifest"
+echo "Synced $(printf '%s\n' "$wanted" | grep -c .) mods" > /dev/termination-log`
+
+// reconcileMods reports the outcome of the Pod's mods container in the ModsSynced condition. A checksum
+// mismatch keeps the game from starting, the kubelet retries the download until the mod is fixed.
+func (r *GameServerReconciler) reconcileMods(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	mods := resolveMods(gs, gameDef)
+	if !modsManaged(gs, mods) {
+		return ctrl.Result{}, nil
+	}
+	pod, err := getGamePod(ctx, r.Client, gs)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to get Pod")
+		return ctrl.Result{}, err
+	}
+	if pod == nil || !slices.ContainsFunc(pod.Spec.InitContainers, func(c corev1.Container) bool { return c.Name == modsContainerName }) {
+		return ctrl.Result{}, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionModsSynced,
+		Status:             metav1.ConditionUnknown,
+		Reason:             "Syncing",
+		Message:            fmt.Sprintf("Syncing %d mods", len(mods)),
+		ObservedGeneration: gs.Generation,
+	}
+	done, failure, message := initContainerProgress(pod, modsContainerName)
+	switch {
+	case strings.Contains(failure, modsChecksumMismatch):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ChecksumMismatch"
+		condition.Message = failure
+	case failure != "":
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SyncFailed"
+		condition.Message = failure
+	case done:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Synced"
+		condition.Message = message
+	}
+	if !meta.SetStatusCondition(&gs.Status.Conditions, condition) {
+		return ctrl.Result{}, nil
+	}
+	if condition.Status == metav1.ConditionFalse {
+		r.Recorder.Eventf(gs, corev1.EventTypeWarning, condition.Reason, "Syncing mods failed: %s", failure)
+	}
+	// modsManaged reads the condition back, it has to be stored even if the state doesn't change.
+	if err := r.Status().Update(ctx, gs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServer status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// resolveMods returns the mods of the GameServer: those of the GameDefinition, replaced or extended by its
+// profile and then by the GameServer, without the ones it removes.
+func resolveMods(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) []v1alpha1.Mod {
+	mods := gameDef.Spec.Mods
+	if _, profile := resolveProfile(gs, gameDef); profile != nil {
+		mods = mergeMods(mods, profile.Mods)
+	}
+	mods = mergeMods(mods, gs.Spec.Mods)
+	return slices.DeleteFunc(mods, func(mod v1alpha1.Mod) bool {
+		return slices.Contains(gs.Spec.RemoveMods, mod.Name)
+	})
+}
+
+// mergeMods merges two lists of mods by name. Mods in the override list take precedence, the order of first
+// appearance is kept so the rendered Pod spec is stable between reconciles.
+func mergeMods(base, override []v1alpha1.Mod) []v1alpha1.Mod {
+	merged := make([]v1alpha1.Mod, 0, len(base)+len(override))
+	index := make(map[string]int)
+	for _, mod := range append(append([]v1alpha1.Mod{}, base...), override...) {
+		if i, ok := index[mod.Name]; ok {
+			merged[i] = mod
+			continue
+		}
+		index[mod.Name] = len(merged)
+		merged = append(merged, mod)
+	}
+	return merged
+}
+
+// modsManaged reports whether the Pod gets a mods container. Once mods were synced the container stays, even
+// with an empty list, so it removes the files of the last mods as well.
+func modsManaged(gs *v1alpha1.GameServer, mods []v1alpha1.Mod) bool {
+	return len(mods) > 0 || meta.FindStatusCondition(gs.Status.Conditions, v1alpha1.ConditionModsSynced) != nil
+}
+
+// buildModsContainer returns the init container syncing the mods into the game data.
+func buildModsContainer(mods []v1alpha1.Mod, image string) corev1.Container {
+	lines := make([]string, 0, len(mods))
+	for _, mod := range mods {
+		lines = append(lines, strings.Join([]string{mod.Name, strings.ToLower(mod.SHA256), mod.Path, mod.URL}, " "))
+	}
+	return corev1.Container{
+		Name:         modsContainerName,
+		Image:        image,
+		Command:      []string{"sh", "-c", modsScript},
+		Env:          []corev1.EnvVar{{Name: "MODS", Value: strings.Join(lines, "\n")}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "game-data", MountPath: "/data"}},
+	}
+}
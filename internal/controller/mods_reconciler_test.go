@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Mods", func() {
+	const (
+		essentialsSum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		worldEditSum  = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+	)
+	var (
+		ctx     = context.Background()
+		gs      *kraftnetescomv1alpha1.GameServer
+		gameDef *kraftnetescomv1alpha1.GameDefinition
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default", UID: "uid-survival"},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft", Profile: "paper"},
+		}
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:  "minecraft",
+				Image: "itzg/minecraft-server",
+				Mods: []kraftnetescomv1alpha1.Mod{
+					{Name: "essentials", URL: "https://example.com/EssentialsX-2.20.jar", SHA256: essentialsSum, Path: "plugins/EssentialsX.jar"},
+					{Name: "worldedit", URL: "https://example.com/WorldEdit.jar", SHA256: worldEditSum, Path: "plugins/WorldEdit.jar"},
+				},
+				Profiles: &kraftnetescomv1alpha1.GameProfiles{Values: []kraftnetescomv1alpha1.GameProfile{{
+					Name: "paper",
+					Mods: []kraftnetescomv1alpha1.Mod{
+						{Name: "essentials", URL: "https://example.com/EssentialsX-2.21.jar", SHA256: essentialsSum, Path: "plugins/EssentialsX.jar"},
+					},
+				}}},
+			},
+		}
+	})
+
+	It("merges the mods of the GameDefinition, profile and GameServer by name", func() {
+		gs.Spec.Mods = []kraftnetescomv1alpha1.Mod{
+			{Name: "luckperms", URL: "https://example.com/LuckPerms.jar", SHA256: worldEditSum, Path: "plugins/LuckPerms.jar"},
+		}
+		gs.Spec.RemoveMods = []string{"worldedit"}
+		mods := resolveMods(gs, gameDef)
+		Expect(mods).To(HaveLen(2))
+		Expect(mods[0].URL).To(Equal("https://example.com/EssentialsX-2.21.jar"))
+		Expect(mods[1].Name).To(Equal("luckperms"))
+		Expect(gameDef.Spec.Mods).To(HaveLen(2))
+		Expect(ValidateGameServer(gs, gameDef)).To(BeEmpty())
+	})
+
+	It("rejects removals of unknown mods and clashing paths", func() {
+		gs.Spec.RemoveMods = []string{"dynmap"}
+		errs := ValidateGameServer(gs, gameDef)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.removeMods[0]"))
+
+		gs.Spec.RemoveMods = nil
+		gs.Spec.Mods = []kraftnetescomv1alpha1.Mod{
+			{Name: "fawe", URL: "https://example.com/FastAsyncWorldEdit.jar", SHA256: worldEditSum, Path: "plugins/WorldEdit.jar"},
+		}
+		errs = ValidateGameServer(gs, gameDef)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.mods"))
+		Expect(errs[0].Detail).To(ContainSubstring("used by another mod"))
+	})
+
+	It("syncs the mods in an init container that is part of the spec hash", func() {
+		pod, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.InitContainers).To(HaveLen(1))
+		mods := pod.Spec.InitContainers[0]
+		Expect(mods.Name).To(Equal(modsContainerName))
+		Expect(mods.Env).To(ConsistOf(corev1.EnvVar{Name: "MODS", Value: "" +
+			"essentials " + essentialsSum + " plugins/EssentialsX.jar https://example.com/EssentialsX-2.21.jar\n" +
+			"worldedit " + worldEditSum + " plugins/WorldEdit.jar https://example.com/WorldEdit.jar"}))
+
+		gs.Spec.RemoveMods = []string{"worldedit"}
+		updated, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Annotations[podSpecHashAnnotation]).NotTo(Equal(pod.Annotations[podSpecHashAnnotation]))
+	})
+
+	It("keeps the mods container once mods were synced, so it removes the last ones", func() {
+		gameDef.Spec.Mods = nil
+		gameDef.Spec.Profiles = nil
+		pod, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.InitContainers).To(BeEmpty())
+
+		meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+			Type: kraftnetescomv1alpha1.ConditionModsSynced, Status: metav1.ConditionTrue, Reason: "Synced",
+		})
+		pod, err = buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.InitContainers).To(HaveLen(1))
+		Expect(pod.Spec.InitContainers[0].Env[0].Value).To(BeEmpty())
+	})
+
+	It("reports checksum mismatches in the ModsSynced condition", func() {
+		pod, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name: modsContainerName,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1,
+				Message:  "checksum mismatch for mod essentials from https://example.com/EssentialsX-2.21.jar: expected 9f86, got 2c26",
+			}},
+		}}
+		reconciler, recorder := newFakeGameServerReconciler(gs, pod)
+
+		_, err = reconciler.reconcileMods(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(gs), gs)).To(Succeed())
+		condition := meta.FindStatusCondition(gs.Status.Conditions, kraftnetescomv1alpha1.ConditionModsSynced)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("ChecksumMismatch"))
+		Expect(condition.Message).To(ContainSubstring("mod essentials"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("ChecksumMismatch")))
+
+		// The event isn't repeated while the condition stays the same.
+		_, err = reconciler.reconcileMods(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).NotTo(Receive())
+
+		// The fixed download succeeds on the next attempt of the kubelet.
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:                 modsContainerName,
+			State:                corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "Synced 2 mods\n"}},
+			LastTerminationState: pod.Status.InitContainerStatuses[0].LastTerminationState,
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		_, err = reconciler.reconcileMods(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(gs), gs)).To(Succeed())
+		condition = meta.FindStatusCondition(gs.Status.Conditions, kraftnetescomv1alpha1.ConditionModsSynced)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("Synced 2 mods"))
+		// The stored condition keeps the mods container once the last mods are removed.
+		Expect(modsManaged(gs, nil)).To(BeTrue())
+	})
+})
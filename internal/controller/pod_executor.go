@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// attachTimeout bounds how long we keep an attach stream open after writing to stdin.
+// The stream normally only ends once the container exits, so running into it is expected.
+const attachTimeout = 5 * time.Second
+
+// PodExecutor talks to running game containers through the pods/exec and pods/attach subresources.
+type PodExecutor interface {
+	// Exec runs command inside the given container and waits for it to finish.
+	Exec(ctx context.Context, pod *corev1.Pod, container string, command []string) error
+	// SendStdin writes a single line to the attached stdin of the given container.
+	SendStdin(ctx context.Context, pod *corev1.Pod, container string, line string) error
+}
+
+type remotePodExecutor struct {
+	config *rest.Config
+	client rest.Interface
+}
+
+// NewPodExecutor returns a PodExecutor that uses the API server's SPDY streaming endpoints.
+func NewPodExecutor(config *rest.Config) (PodExecutor, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return &remotePodExecutor{config: config, client: clientset.CoreV1().RESTClient()}, nil
+}
+
+// Exec runs command inside the container and returns an error containing stderr if it fails.
+func (e *remotePodExecutor) Exec(ctx context.Context, pod *corev1.Pod, container string, command []string) error {
+	req := e.client.Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create exec stream: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := exec.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("command %q failed: %w: %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// SendStdin attaches to the container and writes line followed by a newline to its stdin.
+func (e *remotePodExecutor) SendStdin(ctx context.Context, pod *corev1.Pod, container string, line string) error {
+	req := e.client.Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: container,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	attach, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create attach stream: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, attachTimeout)
+	defer cancel()
+
+	err = attach.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  strings.NewReader(line + "\n"),
+		Stdout: io.Discard,
+		Tty:    true,
+	})
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to write %q to stdin: %w", line, err)
+	}
+	return nil
+}
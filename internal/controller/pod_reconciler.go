@@ -2,12 +2,17 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"math/rand"
+	"reflect"
 
 	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -16,14 +21,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	// gameContainerName is the name of the container running the game itself.
+	gameContainerName = "game-server"
+	// fileBrowserPort is reserved for the filebrowser sidecar and can't be used by game ports.
+	fileBrowserPort = 8077
+	// podSpecHashAnnotation holds the hash of the rendered PodSpec the Pod was created from.
+	podSpecHashAnnotation = "kraftnetes.com/pod-spec-hash"
+)
+
 // reconcilePod creates a Pod for the GameServer resource by splitting the work into neat helper functions.
-// It checks for an existing Pod, builds the container specs, applies configuration overrides,
-// and finally creates the Pod while setting proper owner references.
+// It builds the container specs, applies configuration overrides, and creates the Pod while setting
+// proper owner references. An existing Pod whose spec hash no longer matches is replaced after a graceful stop.
 func (r *GameServerReconciler) reconcilePod(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	id := ResolveGameServerId(gs)
 	podName := fmt.Sprintf("gs-%s-pod", id)
-	pvcName := fmt.Sprintf("gs-%s-pvc", id)
 
 	// Check if the Pod already exists.
 	pod, err := r.getExistingPod(ctx, podName, gs.Namespace)
@@ -35,40 +48,163 @@ func (r *GameServerReconciler) reconcilePod(ctx context.Context, gs *v1alpha1.Ga
 			return ctrl.Result{}, err
 		}
 	}
+
+	desired, err := buildPod(gs, gameDef, r.SeedImage, logger)
+	if err != nil {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodBuildFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+
 	if pod != nil {
-		// Pod already exists; nothing more to do.
+		return r.reconcilePodDrift(ctx, gs, gameDef, pod, desired)
+	}
+	pod = desired
+	if seed := volumeClaimOptions(gs, gameDef).Seed; seed != nil {
+		seeded, err := r.dataSeeded(ctx, gs, gameDef)
+		if err != nil {
+			logger.Error(err, "Failed to get pvc")
+			return ctrl.Result{}, err
+		}
+		if !seeded {
+			addSeedContainer(pod, seed, r.SeedImage)
+		}
+	}
+
+	// Set GameServer as the owner of the Pod.
+	if err := controllerutil.SetControllerReference(gs, pod, r.Scheme); err != nil {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	// Create the Pod.
+	if err := r.Create(ctx, pod); err != nil {
+		logger.Error(err, "Failed to create Pod")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodCreateFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PodCreated", "Created Pod %s", pod.Name)
+	logger.Info("Created Pod", "name", pod.Name)
+	return ctrl.Result{}, r.setUpToDateCondition(ctx, gs, metav1.ConditionTrue, "Current", "The Pod matches the GameServer spec")
+}
+
+// reconcilePodDrift compares the spec hash and host ports of the running Pod with the desired one. On a
+// mismatch the game is stopped gracefully and the Pod deleted, so the next pass recreates it from the current spec.
+func (r *GameServerReconciler) reconcilePodDrift(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition, pod *corev1.Pod, desired *corev1.Pod) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	desiredHash := desired.Annotations[podSpecHashAnnotation]
+
+	if !pod.DeletionTimestamp.IsZero() {
+		// The old Pod is on its way out; its deletion event brings us back to create the new one.
 		return ctrl.Result{}, nil
 	}
 
+	currentHash, ok := pod.Annotations[podSpecHashAnnotation]
+	if !ok {
+		// Pods created before drift detection existed are adopted as-is rather than restarted on upgrade.
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[podSpecHashAnnotation] = desiredHash
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			logger.Error(err, "Failed to annotate Pod with spec hash")
+			return ctrl.Result{}, err
+		}
+		currentHash = desiredHash
+	}
+
+	if currentHash == desiredHash && reflect.DeepEqual(podHostPorts(pod), podHostPorts(desired)) {
+		return ctrl.Result{}, r.setUpToDateCondition(ctx, gs, metav1.ConditionTrue, "Current", "The Pod matches the GameServer spec")
+	}
+
+	if _, stopping := pod.Annotations[stopRequestedAtAnnotation]; stopping {
+		if err := r.setUpToDateCondition(ctx, gs, metav1.ConditionFalse, "UpdateInProgress",
+			fmt.Sprintf("Stopping Pod %s to apply the changed spec", pod.Name)); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else {
+		if err := r.setUpToDateCondition(ctx, gs, metav1.ConditionFalse, "UpdatePending",
+			fmt.Sprintf("The spec changed, Pod %s will be replaced", pod.Name)); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "UpdatePending", "The spec changed, replacing Pod %s", pod.Name)
+	}
+
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	stopped, requeueAfter, err := r.stopPod(ctx, gs, pod, mergedConfig.StopStrategy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !stopped {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to delete Pod")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodDeleteFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PodReplaced", "Deleted outdated Pod %s", pod.Name)
+	if err := r.setUpToDateCondition(ctx, gs, metav1.ConditionFalse, "UpdateInProgress",
+		fmt.Sprintf("Deleted Pod %s, it will be recreated from the changed spec", pod.Name)); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+}
+
+// buildPod renders the desired Pod of the GameServer, including the hash of its spec. The mods container runs
+// on seedImage.
+func buildPod(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition, seedImage string, logger logr.Logger) (*corev1.Pod, error) {
+	id := ResolveGameServerId(gs)
+	podName := fmt.Sprintf("gs-%s-pod", id)
+	pvcName := fmt.Sprintf("gs-%s-pvc", id)
+
 	// Resolve configuration and environment details.
 	mergedConfig, finalEnv, finalResources := resolveConfigEnvResources(gs, gameDef)
 
 	// Process container ports; note that logger is passed by value (not as a pointer).
-	containerPorts := resolveContainerPorts(&mergedConfig, logger)
+	containerPorts := resolveContainerPorts(gs, &mergedConfig, logger)
 
 	// Build the primary game container.
-	gameContainer := buildGameContainer(mergedConfig, finalEnv, finalResources, containerPorts, gameDef.Spec.Storage.Enabled.BoolVal)
+	gameContainer := buildGameContainer(mergedConfig, finalEnv, finalResources, containerPorts)
 
 	// Start assembling the containers list.
 	containers := []corev1.Container{gameContainer}
 
-	// Determine if the file browser should be enabled.
-	fileBrowserEnabled := gameDef.Spec.FileBrowser.BoolVal
-	if gs.Spec.Filebrowser != nil {
-		fileBrowserEnabled = *gs.Spec.Filebrowser
+	// Build the game data volume, persistent if storage is enabled.
+	volumes, err := buildPodVolumes(pvcName, usesStorage(gs, gameDef), mergedConfig.Storage)
+	if err != nil {
+		return nil, err
 	}
-	if fileBrowserEnabled {
-		containers = append(containers, buildFileBrowserContainer(id))
+
+	// Determine if the file browser should be enabled.
+	var initContainers []corev1.Container
+	if fileBrowserEnabled(gs, gameDef) {
+		config := fileBrowserConfig(gameDef)
+		initContainers = append(initContainers, buildFileBrowserInitContainer(gs, config))
+		containers = append(containers, buildFileBrowserContainer(id, config))
+		volumes = append(volumes, corev1.Volume{
+			Name:         fileBrowserDatabaseVolume,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
 	}
 
-	// Build volumes if storage is enabled.
-	volumes := buildPodVolumes(pvcName, gameDef.Spec.Storage.Enabled.BoolVal)
+	// Sync the mods into the game data before the game starts.
+	if mods := resolveMods(gs, gameDef); modsManaged(gs, mods) {
+		initContainers = append(initContainers, buildModsContainer(mods, seedImage))
+	}
 
 	// Build the Pod specification.
-	podSpec := buildPodSpec(containers, volumes)
+	podSpec := buildPodSpec(initContainers, containers, volumes)
+
+	hash, err := podSpecHash(podSpec)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create the Pod object.
-	pod = &corev1.Pod{
+	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      podName,
 			Namespace: gs.Namespace,
@@ -77,26 +213,66 @@ func (r *GameServerReconciler) reconcilePod(ctx context.Context, gs *v1alpha1.Ga
 				"gameserver":    gs.Name,
 				"kraftnetes-id": gs.Labels["kraftnetes-id"],
 			},
+			Annotations: map[string]string{
+				podSpecHashAnnotation: hash,
+			},
 		},
 		Spec: podSpec,
-	}
+	}, nil
+}
 
-	// Set GameServer as the owner of the Pod.
-	if err := controllerutil.SetControllerReference(gs, pod, r.Scheme); err != nil {
-		r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
-		return ctrl.Result{}, err
+// podSpecHash returns a short, stable hash of the rendered PodSpec.
+// Host ports are left out, so Pods from before host ports were recorded in status are adopted with the hash;
+// reconcilePodDrift compares them separately.
+func podSpecHash(spec corev1.PodSpec) (string, error) {
+	spec = *spec.DeepCopy()
+	for i := range spec.Containers {
+		for j := range spec.Containers[i].Ports {
+			spec.Containers[i].Ports[j].HostPort = 0
+		}
+	}
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal Pod spec: %w", err)
 	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
 
-	// Create the Pod.
-	if err := r.Create(ctx, pod); err != nil {
-		logger.Error(err, "Failed to create Pod")
-		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodCreateFailed", err.Error())
-		return ctrl.Result{}, err
+// podHostPorts returns the host ports of the Pod's game container by port name.
+func podHostPorts(pod *corev1.Pod) map[string]int32 {
+	ports := map[string]int32{}
+	for _, container := range pod.Spec.Containers {
+		if container.Name != gameContainerName {
+			continue
+		}
+		for _, cp := range container.Ports {
+			if cp.HostPort != 0 {
+				ports[cp.Name] = cp.HostPort
+			}
+		}
 	}
+	return ports
+}
 
-	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PodCreated", "Created Pod %s", pod.Name)
-	logger.Info("Created Pod", "name", pod.Name)
-	return ctrl.Result{}, nil
+// setUpToDateCondition records whether the running Pod matches the GameServer spec and writes the status if the
+// condition changed. updateStatus only writes what it derives itself.
+func (r *GameServerReconciler) setUpToDateCondition(ctx context.Context, gs *v1alpha1.GameServer, status metav1.ConditionStatus, reason, message string) error {
+	changed := meta.SetStatusCondition(&gs.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionUpToDate,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: gs.Generation,
+	})
+	if !changed {
+		return nil
+	}
+	if err := r.Status().Update(ctx, gs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServer status")
+		return err
+	}
+	return nil
 }
 
 // getExistingPod checks if a Pod with the given name exists.
@@ -110,50 +286,27 @@ func (r *GameServerReconciler) getExistingPod(ctx context.Context, podName, name
 }
 
 // buildGameContainer returns the container spec for the game server.
-func buildGameContainer(mergedConfig v1alpha1.GameDefinitionSpec, finalEnv []corev1.EnvVar, finalResources corev1.ResourceRequirements, containerPorts []corev1.ContainerPort, storageEnabled bool) corev1.Container {
-	container := corev1.Container{
-		Name:      "game-server",
+func buildGameContainer(mergedConfig v1alpha1.GameDefinitionSpec, finalEnv []corev1.EnvVar, finalResources corev1.ResourceRequirements, containerPorts []corev1.ContainerPort) corev1.Container {
+	return corev1.Container{
+		Name:      gameContainerName,
 		Image:     mergedConfig.Image,
 		TTY:       true,
 		Stdin:     true,
 		Ports:     containerPorts,
 		Env:       finalEnv,
 		Resources: finalResources,
-	}
-	if storageEnabled {
-		container.VolumeMounts = []corev1.VolumeMount{
-			{
-				Name:      "game-data",
-				MountPath: "/data",
-			},
-		}
-	}
-	return container
-}
-
-// buildFileBrowserContainer returns the container spec for the file browser.
-func buildFileBrowserContainer(id string) corev1.Container {
-	return corev1.Container{
-		Name:  "filebrowser",
-		Image: "filebrowser/filebrowser",
-		Ports: []corev1.ContainerPort{
-			{
-				ContainerPort: 8077,
-				Protocol:      corev1.ProtocolTCP,
-			},
-		},
-		Args: []string{"--port", "8077", "--baseurl", "/files/" + id},
 		VolumeMounts: []corev1.VolumeMount{
 			{
 				Name:      "game-data",
-				MountPath: "/srv",
+				MountPath: "/data",
 			},
 		},
 	}
 }
 
-// buildPodVolumes returns the volumes spec for the Pod if storage is enabled.
-func buildPodVolumes(pvcName string, storageEnabled bool) []corev1.Volume {
+// buildPodVolumes returns the game-data volume: the PVC if storage is enabled, else an emptyDir limited to the
+// storage sizeLimit.
+func buildPodVolumes(pvcName string, storageEnabled bool, storage *v1alpha1.StorageConfig) ([]corev1.Volume, error) {
 	if storageEnabled {
 		return []corev1.Volume{
 			{
@@ -164,21 +317,32 @@ func buildPodVolumes(pvcName string, storageEnabled bool) []corev1.Volume {
 					},
 				},
 			},
+		}, nil
+	}
+
+	emptyDir := &corev1.EmptyDirVolumeSource{}
+	if storage != nil && storage.SizeLimit != "" {
+		sizeLimit, err := resource.ParseQuantity(storage.SizeLimit)
+		if err != nil {
+			return nil, fmt.Errorf("storage sizeLimit %q is not a valid quantity: %w", storage.SizeLimit, err)
 		}
+		emptyDir.SizeLimit = &sizeLimit
 	}
-	return nil
+	return []corev1.Volume{{Name: "game-data", VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir}}}, nil
 }
 
 // buildPodSpec returns the PodSpec from given containers and volumes.
-func buildPodSpec(containers []corev1.Container, volumes []corev1.Volume) corev1.PodSpec {
+func buildPodSpec(initContainers, containers []corev1.Container, volumes []corev1.Volume) corev1.PodSpec {
 	return corev1.PodSpec{
-		Containers: containers,
-		Volumes:    volumes,
+		InitContainers: initContainers,
+		Containers:     containers,
+		Volumes:        volumes,
 	}
 }
 
-// resolveContainerPorts processes container ports: if a port's type is "HostPort", assign a random host port.
-func resolveContainerPorts(mergedConfig *v1alpha1.GameDefinitionSpec, logger logr.Logger) []corev1.ContainerPort {
+// resolveContainerPorts processes container ports: if a port's type is "HostPort", attach the host port
+// allocated to it by reconcileHostPorts.
+func resolveContainerPorts(gs *v1alpha1.GameServer, mergedConfig *v1alpha1.GameDefinitionSpec, logger logr.Logger) []corev1.ContainerPort {
 	var containerPorts []corev1.ContainerPort
 	for _, gp := range mergedConfig.Ports {
 		cp := corev1.ContainerPort{
@@ -186,8 +350,8 @@ func resolveContainerPorts(mergedConfig *v1alpha1.GameDefinitionSpec, logger log
 			Name:          gp.Name,
 			Protocol:      corev1.Protocol(gp.Protocol),
 		}
-		if gp.Type == "HostPort" {
-			hostPort := resolveHostPort()
+		if gp.Type == portTypeHostPort {
+			hostPort := allocatedHostPort(gs, gp.Name)
 			logger.Info("Attaching host port", "hostPort", hostPort)
 			cp.HostPort = hostPort
 		}
@@ -201,29 +365,16 @@ func resolveContainerPorts(mergedConfig *v1alpha1.GameDefinitionSpec, logger log
 func resolveConfigEnvResources(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (v1alpha1.GameDefinitionSpec, []corev1.EnvVar, corev1.ResourceRequirements) {
 	mergedConfig := gameDef.Spec
 
-	// Determine which profile to apply.
-	profileName := gs.Spec.Profile
-	if profileName == "" && gameDef.Spec.Profiles != nil && gameDef.Spec.Profiles.Default != "" {
-		profileName = gameDef.Spec.Profiles.Default
-	}
-
-	var chosenProfile *v1alpha1.GameProfile
-	if profileName != "" && gameDef.Spec.Profiles != nil {
-		// Avoid the common pitfall of taking the address of the loop variable.
-		for i := range gameDef.Spec.Profiles.Values {
-			if gameDef.Spec.Profiles.Values[i].Name == profileName {
-				chosenProfile = &gameDef.Spec.Profiles.Values[i]
-				break
-			}
-		}
-	}
+	_, chosenProfile := resolveProfile(gs, gameDef)
 
 	// If a profile was found, patch the base configuration with its settings.
 	if chosenProfile != nil {
 		if chosenProfile.Image != "" {
 			mergedConfig.Image = chosenProfile.Image
 		}
-		mergedConfig.FileBrowser = chosenProfile.FileBrowser
+		if chosenProfile.FileBrowser != nil {
+			mergedConfig.FileBrowser = *chosenProfile.FileBrowser
+		}
 		if chosenProfile.StopStrategy != nil {
 			mergedConfig.StopStrategy = chosenProfile.StopStrategy
 		}
@@ -245,23 +396,37 @@ func resolveConfigEnvResources(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDe
 	return mergedConfig, finalEnv, finalResources
 }
 
-// resolveHostPort returns a random host port in the range [30000, 33332].
-func resolveHostPort() int32 {
-	return rand.Int31n(3333) + 30000
+// resolveProfile returns the name of the profile the GameServer uses, falling back to the GameDefinition's
+// default, and the matching profile. The profile is nil if the name is empty or not defined.
+func resolveProfile(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (string, *v1alpha1.GameProfile) {
+	profileName := gs.Spec.Profile
+	if profileName == "" && gameDef.Spec.Profiles != nil && gameDef.Spec.Profiles.Default != "" {
+		profileName = gameDef.Spec.Profiles.Default
+	}
+
+	if profileName != "" && gameDef.Spec.Profiles != nil {
+		// Avoid the common pitfall of taking the address of the loop variable.
+		for i := range gameDef.Spec.Profiles.Values {
+			if gameDef.Spec.Profiles.Values[i].Name == profileName {
+				return profileName, &gameDef.Spec.Profiles.Values[i]
+			}
+		}
+	}
+	return profileName, nil
 }
 
 // mergeEnvVars merges two slices of corev1.EnvVar.
 // Variables in the override slice take precedence over those in the base slice.
+// The order of first appearance is kept so the rendered Pod spec is stable between reconciles.
 func mergeEnvVars(base, override []corev1.EnvVar) []corev1.EnvVar {
-	envMap := make(map[string]corev1.EnvVar)
-	for _, env := range base {
-		envMap[env.Name] = env
-	}
-	for _, env := range override {
-		envMap[env.Name] = env
-	}
-	merged := make([]corev1.EnvVar, 0, len(envMap))
-	for _, env := range envMap {
+	merged := make([]corev1.EnvVar, 0, len(base)+len(override))
+	index := make(map[string]int)
+	for _, env := range append(append([]corev1.EnvVar{}, base...), override...) {
+		if i, ok := index[env.Name]; ok {
+			merged[i] = env
+			continue
+		}
+		index[env.Name] = len(merged)
 		merged = append(merged, env)
 	}
 	return merged
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Pod rendering", func() {
+	It("merges env vars in a stable order with overrides replacing in place", func() {
+		merged := mergeEnvVars(
+			[]corev1.EnvVar{{Name: "EULA", Value: "TRUE"}, {Name: "VERSION", Value: "1.21.4"}, {Name: "TYPE", Value: "VANILLA"}},
+			[]corev1.EnvVar{{Name: "VERSION", Value: "1.21.5"}, {Name: "MOTD", Value: "hello"}},
+		)
+		Expect(merged).To(Equal([]corev1.EnvVar{
+			{Name: "EULA", Value: "TRUE"},
+			{Name: "VERSION", Value: "1.21.5"},
+			{Name: "TYPE", Value: "VANILLA"},
+			{Name: "MOTD", Value: "hello"},
+		}))
+	})
+
+	It("hashes the Pod spec independently of host ports", func() {
+		spec := corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  gameContainerName,
+			Image: "itzg/minecraft-server",
+			Ports: []corev1.ContainerPort{{ContainerPort: 25565, HostPort: 30001}},
+		}}}
+		hash, err := podSpecHash(spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		otherPort := *spec.DeepCopy()
+		otherPort.Containers[0].Ports[0].HostPort = 31234
+		Expect(podSpecHash(otherPort)).To(Equal(hash))
+
+		otherImage := *spec.DeepCopy()
+		otherImage.Containers[0].Image = "itzg/minecraft-server:java21"
+		Expect(podSpecHash(otherImage)).NotTo(Equal(hash))
+	})
+
+	It("keeps the game data in an emptyDir without storage", func() {
+		volumes, err := buildPodVolumes("gs-abc123-pvc", false, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(volumes).To(HaveLen(1))
+		Expect(volumes[0].Name).To(Equal("game-data"))
+		Expect(volumes[0].EmptyDir).NotTo(BeNil())
+		Expect(volumes[0].EmptyDir.SizeLimit).To(BeNil())
+
+		volumes, err = buildPodVolumes("gs-abc123-pvc", false, &kraftnetescomv1alpha1.StorageConfig{SizeLimit: "2Gi"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(volumes[0].EmptyDir.SizeLimit.String()).To(Equal("2Gi"))
+
+		volumes, err = buildPodVolumes("gs-abc123-pvc", true, &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromBool(true)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("gs-abc123-pvc"))
+	})
+
+	It("builds a Pod for a definition without storage", func() {
+		gs := &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft"},
+		}
+		gameDef := &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			Game:        "minecraft",
+			Image:       "itzg/minecraft-server",
+			FileBrowser: kraftnetescomv1alpha1.FromBool(true),
+		}}
+		Expect(usesStorage(gs, gameDef)).To(BeFalse())
+		pod, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+
+		var volumeNames []string
+		for _, volume := range pod.Spec.Volumes {
+			volumeNames = append(volumeNames, volume.Name)
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, mount := range container.VolumeMounts {
+				Expect(volumeNames).To(ContainElement(mount.Name))
+			}
+		}
+	})
+})
+
+var _ = Describe("Pod drift", func() {
+	var (
+		ctx        = context.Background()
+		reconciler *GameServerReconciler
+		executor   *fakePodExecutor
+		gs         *kraftnetescomv1alpha1.GameServer
+		gameDef    *kraftnetescomv1alpha1.GameDefinition
+		pod        *corev1.Pod
+		podKey     = types.NamespacedName{Name: "gs-abc123-pod", Namespace: "default"}
+	)
+
+	upToDate := func() *metav1.Condition {
+		stored := &kraftnetescomv1alpha1.GameServer{}
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(gs), stored)).To(Succeed())
+		return meta.FindStatusCondition(stored.Status.Conditions, kraftnetescomv1alpha1.ConditionUpToDate)
+	}
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft"},
+			Status: kraftnetescomv1alpha1.GameServerStatus{
+				State:     kraftnetescomv1alpha1.GameServerReady,
+				HostPorts: []kraftnetescomv1alpha1.HostPortStatus{{Name: "minecraft", HostPort: 30001}},
+			},
+		}
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			Game:         "minecraft",
+			Image:        "itzg/minecraft-server",
+			StopStrategy: &kraftnetescomv1alpha1.StopStrategy{Stdin: "stop"},
+			Ports: []kraftnetescomv1alpha1.GamePort{
+				{Name: "minecraft", ContainerPort: intstr.FromInt(25565), Type: portTypeHostPort},
+			},
+		}}
+		var err error
+		pod, err = buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  gameContainerName,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		}
+		executor = &fakePodExecutor{}
+	})
+
+	setup := func() {
+		reconciler, _ = newFakeGameServerReconciler(gs, pod)
+		reconciler.Executor = executor
+	}
+
+	drift := func() (ctrl.Result, error) {
+		current := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, current)).To(Succeed())
+		desired, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		return reconciler.reconcilePodDrift(ctx, gs, gameDef, current, desired)
+	}
+
+	It("stops and then deletes a Pod with an outdated spec hash", func() {
+		pod.Annotations[podSpecHashAnnotation] = "outdated"
+		setup()
+
+		res, err := drift()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(executor.stdin).To(Equal([]string{"stop"}))
+		Expect(upToDate().Reason).To(Equal("UpdatePending"))
+
+		// The game exits, the next pass deletes the Pod.
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		res, err = drift()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(stopPollInterval))
+		Expect(apierrors.IsNotFound(reconciler.Get(ctx, podKey, &corev1.Pod{}))).To(BeTrue())
+		condition := upToDate()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("UpdateInProgress"))
+		Expect(condition.Message).To(ContainSubstring("Deleted Pod gs-abc123-pod"))
+		Expect(executor.stdin).To(HaveLen(1))
+	})
+
+	It("adopts a Pod without a spec hash", func() {
+		delete(pod.Annotations, podSpecHashAnnotation)
+		setup()
+
+		res, err := drift()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsZero()).To(BeTrue())
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.Annotations).To(HaveKey(podSpecHashAnnotation))
+		Expect(upToDate().Status).To(Equal(metav1.ConditionTrue))
+		Expect(executor.stdin).To(BeEmpty())
+	})
+
+	It("replaces a Pod whose host ports changed", func() {
+		setup()
+		gs.Status.HostPorts[0].HostPort = 30002
+
+		_, err := drift()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executor.stdin).To(Equal([]string{"stop"}))
+		condition := upToDate()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("UpdatePending"))
+	})
+})
@@ -6,6 +6,8 @@ import (
 
 	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -15,38 +17,113 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// reconcilePvc creates the game data claim, or adopts one retained for this kraftnetes-id, and expands it when
+// the requested size grows. Claims never shrink, a smaller size is refused in the StorageResizing condition.
 func (r *GameServerReconciler) reconcilePvc(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
-	if !gameDef.Spec.Storage.Enabled.BoolVal {
-		logger.Info("Storage disabled for %s. Skipping...", gameDef.Name)
-		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "Skipped creating pvc for %s", gs.Name)
+	if !usesStorage(gs, gameDef) {
+		// The game data lives in an emptyDir of the Pod.
 		return ctrl.Result{}, nil
 	}
 
 	id := ResolveGameServerId(gs)
 	pvcName := fmt.Sprintf("gs-%s-pvc", id)
 
+	size, err := resolveVolumeSize(gs, gameDef)
+	if err != nil {
+		logger.Error(err, "Failed to resolve volume size")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "InvalidVolumeSize", err.Error())
+		return ctrl.Result{}, err
+	}
+
 	pvc := &corev1.PersistentVolumeClaim{}
 	if err := r.Get(ctx, types.NamespacedName{Name: pvcName, Namespace: gs.Namespace}, pvc); err == nil {
-		return ctrl.Result{}, nil
+		if err := r.updatePvcMetadata(ctx, gs, gameDef, pvc); err != nil {
+			return ctrl.Result{}, err
+		}
+		return r.resizePvc(ctx, gs, pvc, size)
 	} else if client.IgnoreNotFound(err) != nil {
 		logger.Error(err, "Failed to get pvc")
 		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcLookupFailed", err.Error())
 		return ctrl.Result{}, err
 	}
 
-	defaultStorage := "10Gi" //hard coded default storage. can be overriden by game definition or game server
+	pvc = buildPvc(gs, gameDef, pvcName, size)
+	if err := controllerutil.SetControllerReference(gs, pvc, r.Scheme); err != nil {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
+		return ctrl.Result{}, err
+	}
 
-	if gameDef.Spec.Storage.DefaultSize != "" {
-		defaultStorage = gameDef.Spec.Storage.DefaultSize
+	if err := r.Create(ctx, pvc); err != nil {
+		logger.Error(err, "Failed to create Pvc")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcCreateFailed", err.Error())
+		return ctrl.Result{}, err
 	}
 
-	if gs.Spec.VolumeSize != "" {
-		defaultStorage = gs.Spec.VolumeSize
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PvcCreated", "Created Pvc %s", pvc.Name)
+	logger.Info("Created Pvc", "name", pvc.Name)
+	return ctrl.Result{}, nil
+}
+
+// resizePvc requests an expansion of the claim if size is larger than its current request. The StorageClass
+// must allow volume expansion, otherwise the API server rejects the change and it is reported as ResizeFailed.
+func (r *GameServerReconciler) resizePvc(ctx context.Context, gs *v1alpha1.GameServer, pvc *corev1.PersistentVolumeClaim, size resource.Quantity) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch size.Cmp(requested) {
+	case 0:
+		return ctrl.Result{}, nil
+	case -1:
+		if condition := meta.FindStatusCondition(gs.Status.Conditions, v1alpha1.ConditionStorageResizing); condition == nil || condition.Reason != "ShrinkRefused" {
+			r.Recorder.Eventf(gs, corev1.EventTypeWarning, "PvcShrinkRefused",
+				"Volume size %s is smaller than the %s of pvc %s, volumes can only grow", size.String(), requested.String(), pvc.Name)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if pvc.Spec.Resources.Requests == nil {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+	if err := r.Update(ctx, pvc); err != nil {
+		if !apierrors.IsForbidden(err) && !apierrors.IsInvalid(err) {
+			logger.Error(err, "Failed to resize Pvc")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcResizeFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		// Retrying won't help until the StorageClass or the size changes, which triggers a new reconcile.
+		logger.Info("Pvc can't be expanded", "name", pvc.Name, "error", err.Error())
+		condition := metav1.Condition{
+			Type:               v1alpha1.ConditionStorageResizing,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ResizeFailed",
+			Message:            fmt.Sprintf("Can't expand pvc %s to %s: %s", pvc.Name, size.String(), err.Error()),
+			ObservedGeneration: gs.Generation,
+		}
+		if !meta.SetStatusCondition(&gs.Status.Conditions, condition) {
+			return ctrl.Result{}, nil
+		}
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcResizeFailed", condition.Message)
+		if err := r.Status().Update(ctx, gs); err != nil {
+			logger.Error(err, "Failed to update GameServer status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
 	}
 
-	pvc = &corev1.PersistentVolumeClaim{
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PvcResizeRequested", "Requested expansion of pvc %s from %s to %s", pvc.Name, requested.String(), size.String())
+	logger.Info("Requested Pvc expansion", "name", pvc.Name, "from", requested.String(), "to", size.String())
+	return ctrl.Result{}, nil
+}
+
+// buildPvc returns the game data claim with the claim options of the GameServer, else those of its profile or
+// GameDefinition.
+func buildPvc(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition, pvcName string, size resource.Quantity) *corev1.PersistentVolumeClaim {
+	options := volumeClaimOptions(gs, gameDef)
+
+	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pvcName,
 			Namespace: gs.Namespace,
@@ -54,31 +131,103 @@ func (r *GameServerReconciler) reconcilePvc(ctx context.Context, gs *v1alpha1.Ga
 				"app":        "gameserver",
 				"gameserver": gs.Name,
 			},
+			Annotations: retentionAnnotations(gs, gameDef),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
-			AccessModes: []corev1.PersistentVolumeAccessMode{
-				corev1.ReadWriteOnce,
-			},
+			AccessModes: options.AccessModes,
+			VolumeMode:  options.VolumeMode,
 			Resources: corev1.VolumeResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse(defaultStorage),
+					corev1.ResourceStorage: size,
 				},
 			},
 		},
 	}
+	if len(pvc.Spec.AccessModes) == 0 {
+		pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	if options.StorageClassName != "" {
+		pvc.Spec.StorageClassName = &options.StorageClassName
+	}
+	return pvc
+}
 
-	if err := controllerutil.SetControllerReference(gs, pvc, r.Scheme); err != nil {
-		r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
-		return ctrl.Result{}, err
+// volumeClaimOptions merges the GameServer's claim options over those of its profile or GameDefinition.
+func volumeClaimOptions(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) v1alpha1.VolumeClaimOptions {
+	var options v1alpha1.VolumeClaimOptions
+	if storage := storageConfig(gs, gameDef); storage != nil {
+		options = *storage.VolumeClaimOptions.DeepCopy()
 	}
+	if override := gs.Spec.Storage; override != nil {
+		if override.StorageClassName != "" {
+			options.StorageClassName = override.StorageClassName
+		}
+		if len(override.AccessModes) > 0 {
+			options.AccessModes = override.AccessModes
+		}
+		if override.VolumeMode != nil {
+			options.VolumeMode = override.VolumeMode
+		}
+		if override.Seed != nil {
+			options.Seed = override.Seed
+		}
+	}
+	return options
+}
 
-	if err := r.Create(ctx, pvc); err != nil {
-		logger.Error(err, "Failed to create Pvc")
-		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcCreateFailed", err.Error())
-		return ctrl.Result{}, err
+// storageResizingCondition reports an expansion of the claim that is still pending, or a requested size the
+// claim can't shrink to. ok is false when there is nothing to report and no earlier resize to conclude.
+func storageResizingCondition(conditions []metav1.Condition, pvc *corev1.PersistentVolumeClaim, size resource.Quantity) (metav1.Condition, bool) {
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity, hasCapacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	previous := meta.FindStatusCondition(conditions, v1alpha1.ConditionStorageResizing)
+	condition := metav1.Condition{Type: v1alpha1.ConditionStorageResizing, Status: metav1.ConditionFalse}
+
+	switch {
+	case size.Cmp(requested) < 0:
+		condition.Reason = "ShrinkRefused"
+		condition.Message = fmt.Sprintf("Volume size %s is smaller than the %s of pvc %s, volumes can only grow", size.String(), requested.String(), pvc.Name)
+	case size.Cmp(requested) > 0:
+		if previous != nil && previous.Reason == "ResizeFailed" {
+			return *previous, true
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ResizeRequested"
+		condition.Message = fmt.Sprintf("Expanding pvc %s to %s", pvc.Name, size.String())
+	case hasCapacity && capacity.Cmp(requested) < 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Resizing"
+		condition.Message = fmt.Sprintf("Waiting for the volume of pvc %s to grow from %s to %s", pvc.Name, capacity.String(), requested.String())
+		for _, c := range pvc.Status.Conditions {
+			if c.Type == corev1.PersistentVolumeClaimFileSystemResizePending && c.Status == corev1.ConditionTrue {
+				condition.Reason = "FileSystemResizePending"
+				condition.Message = fmt.Sprintf("The volume of pvc %s grew to %s, its file system is resized when the Pod mounts it", pvc.Name, requested.String())
+			}
+		}
+	default:
+		if previous == nil {
+			return metav1.Condition{}, false
+		}
+		condition.Reason = "Resized"
+		condition.Message = fmt.Sprintf("Pvc %s has %s", pvc.Name, requested.String())
 	}
+	return condition, true
+}
 
-	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PvcCreated", "Created Pvc %s", pvc.Name)
-	logger.Info("Created Pvc", "name", pvc.Name)
-	return ctrl.Result{}, nil
+// resolveVolumeSize returns the requested size of the game data volume: the GameServer's volumeSize,
+// else the storage defaultSize of its profile or GameDefinition, else 10Gi.
+func resolveVolumeSize(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (resource.Quantity, error) {
+	size := "10Gi" //hard coded default storage. can be overriden by game definition or game server
+	if storage := storageConfig(gs, gameDef); storage != nil && storage.DefaultSize != "" {
+		size = storage.DefaultSize
+	}
+	if gs.Spec.VolumeSize != "" {
+		size = gs.Spec.VolumeSize
+	}
+
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("volume size %q is not a valid quantity: %w", size, err)
+	}
+	return quantity, nil
 }
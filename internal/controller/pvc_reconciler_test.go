@@ -0,0 +1,114 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Pvc rendering", func() {
+	var (
+		gs      *kraftnetescomv1alpha1.GameServer
+		gameDef *kraftnetescomv1alpha1.GameDefinition
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft"},
+		}
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			Game:  "minecraft",
+			Image: "itzg/minecraft-server",
+			Storage: &kraftnetescomv1alpha1.StorageConfig{
+				Enabled: kraftnetescomv1alpha1.FromBool(true),
+				VolumeClaimOptions: kraftnetescomv1alpha1.VolumeClaimOptions{
+					StorageClassName: "standard",
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				},
+			},
+		}}
+	})
+
+	It("defaults to a ReadWriteOnce claim of the cluster's default class", func() {
+		gameDef.Spec.Storage.VolumeClaimOptions = kraftnetescomv1alpha1.VolumeClaimOptions{}
+		pvc := buildPvc(gs, gameDef, "gs-abc123-pvc", resource.MustParse("10Gi"))
+		Expect(pvc.Spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}))
+		Expect(pvc.Spec.StorageClassName).To(BeNil())
+		Expect(pvc.Spec.VolumeMode).To(BeNil())
+	})
+
+	It("applies the claim options with the GameServer's overrides", func() {
+		pvc := buildPvc(gs, gameDef, "gs-abc123-pvc", resource.MustParse("10Gi"))
+		Expect(*pvc.Spec.StorageClassName).To(Equal("standard"))
+		Expect(pvc.Spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}))
+
+		filesystem := corev1.PersistentVolumeFilesystem
+		gs.Spec.Storage = &kraftnetescomv1alpha1.VolumeClaimOptions{StorageClassName: "fast-ssd", VolumeMode: &filesystem}
+		pvc = buildPvc(gs, gameDef, "gs-abc123-pvc", resource.MustParse("10Gi"))
+		Expect(*pvc.Spec.StorageClassName).To(Equal("fast-ssd"))
+		Expect(pvc.Spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}))
+		Expect(*pvc.Spec.VolumeMode).To(Equal(corev1.PersistentVolumeFilesystem))
+	})
+
+	Context("resizing", func() {
+		pvcWith := func(requested, capacity string) *corev1.PersistentVolumeClaim {
+			return &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "gs-abc123-pvc"},
+				Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)},
+				}},
+				Status: corev1.PersistentVolumeClaimStatus{
+					Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+				},
+			}
+		}
+
+		It("reports nothing for a claim that never resized", func() {
+			_, ok := storageResizingCondition(nil, pvcWith("10Gi", "10Gi"), resource.MustParse("10Gi"))
+			Expect(ok).To(BeFalse())
+		})
+
+		It("reports a pending expansion until the capacity grew", func() {
+			condition, ok := storageResizingCondition(nil, pvcWith("10Gi", "10Gi"), resource.MustParse("20Gi"))
+			Expect(ok).To(BeTrue())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("ResizeRequested"))
+
+			pvc := pvcWith("20Gi", "10Gi")
+			condition, _ = storageResizingCondition(nil, pvc, resource.MustParse("20Gi"))
+			Expect(condition.Reason).To(Equal("Resizing"))
+
+			pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+				Type:   corev1.PersistentVolumeClaimFileSystemResizePending,
+				Status: corev1.ConditionTrue,
+			}}
+			condition, _ = storageResizingCondition(nil, pvc, resource.MustParse("20Gi"))
+			Expect(condition.Reason).To(Equal("FileSystemResizePending"))
+
+			conditions := []metav1.Condition{condition}
+			condition, ok = storageResizingCondition(conditions, pvcWith("20Gi", "20Gi"), resource.MustParse("20Gi"))
+			Expect(ok).To(BeTrue())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("Resized"))
+		})
+
+		It("refuses to shrink", func() {
+			condition, ok := storageResizingCondition(nil, pvcWith("20Gi", "20Gi"), resource.MustParse("10Gi"))
+			Expect(ok).To(BeTrue())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("ShrinkRefused"))
+			Expect(condition.Message).To(ContainSubstring("can only grow"))
+		})
+
+		It("keeps a failed expansion until the size changes", func() {
+			failed := []metav1.Condition{{Type: kraftnetescomv1alpha1.ConditionStorageResizing, Status: metav1.ConditionFalse, Reason: "ResizeFailed"}}
+			condition, _ := storageResizingCondition(failed, pvcWith("10Gi", "10Gi"), resource.MustParse("20Gi"))
+			Expect(condition.Reason).To(Equal("ResizeFailed"))
+		})
+	})
+})
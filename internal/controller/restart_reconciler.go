@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// restartRequestedAtAnnotation requests a restart when set to an RFC3339 timestamp newer than the last restart.
+const restartRequestedAtAnnotation = "kraftnetes.com/restart-requested-at"
+
+// reconcileRestart carries out restarts requested through spec.restartRequestedAt or the restart annotation.
+// The RestartStrategy command is preferred; without one (or if it fails) the game is stopped gracefully
+// and its Pod deleted, so reconcilePod recreates it on the next pass.
+func (r *GameServerReconciler) reconcileRestart(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	requestedAt := r.restartRequestedAt(gs)
+	if requestedAt == nil {
+		return ctrl.Result{}, nil
+	}
+
+	last := gs.Status.LastRestart
+	inProgress := last != nil && last.Result == v1alpha1.RestartInProgress && last.RequestedAt.Equal(requestedAt)
+	if last != nil && !requestedAt.After(last.RequestedAt.Time) && !inProgress {
+		return ctrl.Result{}, nil
+	}
+
+	id := ResolveGameServerId(gs)
+	podName := fmt.Sprintf("gs-%s-pod", id)
+
+	pod, err := r.getExistingPod(ctx, podName, gs.Namespace)
+	if err != nil && client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to get Pod")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodLookupFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+	if pod == nil {
+		return ctrl.Result{}, r.finishRestart(ctx, gs, *requestedAt, v1alpha1.RestartMethodRecreate, v1alpha1.RestartSucceeded,
+			"No Pod was running, it will be created fresh")
+	}
+
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+
+	if !inProgress {
+		restartStrategy := mergedConfig.RestartStrategy
+		if restartStrategy != nil && len(restartStrategy.Cmd) > 0 && r.Executor != nil && pod.Status.Phase == corev1.PodRunning {
+			err := r.Executor.Exec(ctx, pod, gameContainerName, restartStrategy.Cmd)
+			if err == nil {
+				return ctrl.Result{}, r.finishRestart(ctx, gs, *requestedAt, v1alpha1.RestartMethodCmd, v1alpha1.RestartSucceeded,
+					"Restart command completed")
+			}
+			logger.Error(err, "Restart command failed, falling back to recreating the Pod")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "RestartCmdFailed", err.Error())
+		}
+
+		gs.Status.LastRestart = &v1alpha1.RestartStatus{
+			RequestedAt: *requestedAt,
+			Method:      v1alpha1.RestartMethodRecreate,
+			Result:      v1alpha1.RestartInProgress,
+			Message:     fmt.Sprintf("Stopping Pod %s", pod.Name),
+		}
+		if err := r.Status().Update(ctx, gs); err != nil {
+			logger.Error(err, "Failed to update GameServer status")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "Restarting", "Restarting by recreating Pod %s", pod.Name)
+	}
+
+	stopped, requeueAfter, err := r.stopPod(ctx, gs, pod, mergedConfig.StopStrategy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !stopped {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to delete Pod")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodDeleteFailed", err.Error())
+		if statusErr := r.finishRestart(ctx, gs, *requestedAt, v1alpha1.RestartMethodRecreate, v1alpha1.RestartFailed, err.Error()); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.finishRestart(ctx, gs, *requestedAt, v1alpha1.RestartMethodRecreate, v1alpha1.RestartSucceeded,
+		fmt.Sprintf("Deleted Pod %s, it will be recreated", pod.Name)); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Stop here; the Pod deletion event brings us back once the old Pod is gone.
+	return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+}
+
+// restartRequestedAt returns the most recent restart request from the spec field and the annotation.
+func (r *GameServerReconciler) restartRequestedAt(gs *v1alpha1.GameServer) *metav1.Time {
+	requestedAt := gs.Spec.RestartRequestedAt
+
+	if value, ok := gs.Annotations[restartRequestedAtAnnotation]; ok && value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			r.Recorder.Eventf(gs, corev1.EventTypeWarning, "InvalidRestartRequest",
+				"Annotation %s must be an RFC3339 timestamp, got %q", restartRequestedAtAnnotation, value)
+		} else if requestedAt == nil || t.After(requestedAt.Time) {
+			// The status keeps whole seconds only, a fractional request would never count as carried out.
+			requestedAt = &metav1.Time{Time: t.Truncate(time.Second)}
+		}
+	}
+	return requestedAt
+}
+
+// finishRestart records the outcome of a restart in the GameServer status and as an Event.
+func (r *GameServerReconciler) finishRestart(ctx context.Context, gs *v1alpha1.GameServer, requestedAt metav1.Time, method v1alpha1.RestartMethod, result v1alpha1.RestartResult, message string) error {
+	now := metav1.Now()
+	gs.Status.LastRestart = &v1alpha1.RestartStatus{
+		RequestedAt: requestedAt,
+		CompletedAt: &now,
+		Method:      method,
+		Result:      result,
+		Message:     message,
+	}
+	if err := r.Status().Update(ctx, gs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServer status")
+		return err
+	}
+
+	if result == v1alpha1.RestartFailed {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "RestartFailed", message)
+	} else {
+		r.Recorder.Event(gs, corev1.EventTypeNormal, "Restarted", message)
+	}
+	return nil
+}
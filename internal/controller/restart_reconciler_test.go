@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Restart", func() {
+	ctx := context.Background()
+
+	It("carries out an annotation with fractional seconds only once", func() {
+		gs := &kraftnetescomv1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{
+			Name:        "survival",
+			Namespace:   "default",
+			Annotations: map[string]string{restartRequestedAtAnnotation: "2024-05-01T10:00:00.5Z"},
+		}}
+		reconciler, recorder := newFakeGameServerReconciler(gs)
+		gameDef := &kraftnetescomv1alpha1.GameDefinition{}
+
+		_, err := reconciler.reconcileRestart(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive())
+
+		// The status only keeps whole seconds.
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(gs), gs)).To(Succeed())
+		completedAt := gs.Status.LastRestart.CompletedAt
+		_, err = reconciler.reconcileRestart(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "survival", Namespace: "default"}, gs)).To(Succeed())
+		Expect(gs.Status.LastRestart.CompletedAt).To(Equal(completedAt))
+	})
+})
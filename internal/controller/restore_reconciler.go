@@ -0,0 +1,359 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// restoreAnnotation records on the restore Pod which restore request it carries out.
+	restoreAnnotation = "kraftnetes.com/restore"
+	// restoreContainerName extracts the backup into the game data, restoreDownloadContainerName fetches it
+	// from S3 first.
+	restoreContainerName         = "restore"
+	restoreDownloadContainerName = "restore-download"
+)
+
+// restoreScript stages the backup next to the game data and only replaces the data once it is complete, a
+// failed restore leaves the old world in place. Archives are checked against the checksum of the backup. The
+// restore is marked in the data, so init containers rerun by the kubelet don't undo what the game saved since.
+const restoreScript = `set -e
+if [ "$(cat /data/.kraftnetes-restored 2>/dev/null)" = "$RESTORE_KEY" ]; then
+  exit 0
+fi
+staging=/data/.kraftnetes-restore
+rm -rf "$staging"
+mkdir -p "$staging"
+if [ -n "$ARCHIVE" ]; then
+  if [ -n "$CHECKSUM" ]; then
+    echo "${CHECKSUM#sha256:}  $ARCHIVE" | sha256sum -c -
+  fi
+  tar -xzf "$ARCHIVE" -C "$staging"
+else
+  cp -a /restore/source/. "$staging"
+fi
+find /data -mindepth 1 -maxdepth 1 ! -name .kraftnetes-restore -exec rm -rf {} \;
+find "$staging" -mindepth 1 -maxdepth 1 -exec mv {} /data/ \;
+rmdir "$staging"
+echo "$RESTORE_KEY" > /data/.kraftnetes-restored`
+
+// restoreDownloadScript fetches the archive of an S3 backup.
+const restoreDownloadScript = `set -e
+if [ "$(cat /data/.kraftnetes-restored 2>/dev/null)" = "$RESTORE_KEY" ]; then
+  exit 0
+fi
+mc alias set source "$S3_ENDPOINT" "$AWS_ACCESS_KEY_ID" "$AWS_SECRET_ACCESS_KEY" $MC_FLAGS
+mc cp $MC_FLAGS "source/$S3_BUCKET/$S3_KEY" /restore/backup.tar.gz`
+
+// reconcileRestore carries out spec.restoreFrom: the game is stopped gracefully and its Pod replaced by one
+// whose init containers restore the backup before the game starts. The restore Pod keeps the spec hash of the
+// regular Pod, so it simply stays on as the game's Pod afterwards. A new GameServer gets the restore Pod as its
+// first Pod, which seeds it with the backup.
+func (r *GameServerReconciler) reconcileRestore(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	source := gs.Spec.RestoreFrom
+	if source == nil {
+		return ctrl.Result{}, nil
+	}
+	last := gs.Status.LastRestore
+	sameRequest := last != nil && last.Backup == source.Backup && restoreRequestedAt(last.RequestedAt).Equal(restoreRequestedAt(source.RequestedAt))
+	if sameRequest && last.Result != v1alpha1.RestoreInProgress {
+		return ctrl.Result{}, nil
+	}
+	if !sameRequest {
+		gs.Status.LastRestore = &v1alpha1.RestoreStatus{
+			Backup:      source.Backup,
+			RequestedAt: source.RequestedAt,
+			Result:      v1alpha1.RestoreInProgress,
+			Message:     fmt.Sprintf("Restoring backup %s", source.Backup),
+		}
+		if err := r.Status().Update(ctx, gs); err != nil {
+			logger.Error(err, "Failed to update GameServer status")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "RestoreStarted", "Restoring backup %s", source.Backup)
+	}
+
+	backup := &v1alpha1.GameServerBackup{}
+	if err := r.Get(ctx, types.NamespacedName{Name: source.Backup, Namespace: gs.Namespace}, backup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, r.finishRestore(ctx, gs, v1alpha1.RestoreFailed, fmt.Sprintf("GameServerBackup %s not found", source.Backup))
+		}
+		logger.Error(err, "Failed to get GameServerBackup", "backup", source.Backup)
+		return ctrl.Result{}, err
+	}
+	switch backup.Status.Phase {
+	case v1alpha1.BackupCompleted:
+	case v1alpha1.BackupFailed:
+		return ctrl.Result{}, r.finishRestore(ctx, gs, v1alpha1.RestoreFailed, fmt.Sprintf("Backup %s failed, there is nothing to restore", backup.Name))
+	default:
+		// A backup taken right before cloning a server may still be running.
+		return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+	}
+
+	key := restoreKey(source)
+	pod, err := getGamePod(ctx, r.Client, gs)
+	if err != nil {
+		logger.Error(err, "Failed to get Pod")
+		return ctrl.Result{}, err
+	}
+
+	if pod != nil && pod.Annotations[restoreAnnotation] != key {
+		if !pod.DeletionTimestamp.IsZero() {
+			return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+		}
+		mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+		stopped, requeueAfter, err := r.stopPod(ctx, gs, pod, mergedConfig.StopStrategy)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !stopped {
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to delete Pod")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "PodDeleteFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PodStopped", "Stopped Pod %s to restore backup %s", pod.Name, backup.Name)
+		return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+	}
+
+	if pod == nil {
+		if backup.Spec.Target.VolumeSnapshot != nil {
+			if err := r.reconcileRestorePvc(ctx, gs, gameDef, backup); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		pod, err := buildPod(gs, gameDef, r.SeedImage, logger)
+		if err != nil {
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "PodBuildFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		addRestoreContainers(pod, gs, backup, key, r.BackupImage, r.BackupS3Image)
+		if err := controllerutil.SetControllerReference(gs, pod, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, pod); err != nil {
+			logger.Error(err, "Failed to create restore Pod")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "PodCreateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PodCreated", "Created Pod %s restoring backup %s", pod.Name, backup.Name)
+		return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+	}
+
+	done, failure := restoreProgress(pod)
+	if failure != "" {
+		// The restore only swaps the data once complete, the next Pod starts on the old world.
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to delete restore Pod")
+			return ctrl.Result{}, err
+		}
+		if err := r.deleteRestorePvc(ctx, gs); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.finishRestore(ctx, gs, v1alpha1.RestoreFailed, fmt.Sprintf("Restoring backup %s failed: %s", backup.Name, failure))
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+	}
+	if err := r.deleteRestorePvc(ctx, gs); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.finishRestore(ctx, gs, v1alpha1.RestoreSucceeded, fmt.Sprintf("Restored backup %s", backup.Name))
+}
+
+// finishRestore records the outcome of a restore in the GameServer status and as an Event.
+func (r *GameServerReconciler) finishRestore(ctx context.Context, gs *v1alpha1.GameServer, result v1alpha1.RestoreResult, message string) error {
+	gs.Status.LastRestore.Result = result
+	gs.Status.LastRestore.Message = message
+	gs.Status.LastRestore.CompletedAt = metaNow()
+	if err := r.Status().Update(ctx, gs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServer status")
+		return err
+	}
+
+	if result == v1alpha1.RestoreFailed {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "RestoreFailed", message)
+	} else {
+		r.Recorder.Event(gs, corev1.EventTypeNormal, "Restored", message)
+	}
+	return nil
+}
+
+// reconcileRestorePvc creates the claim restoring a VolumeSnapshot backup, the restore container copies it
+// into the game data.
+func (r *GameServerReconciler) reconcileRestorePvc(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition, backup *v1alpha1.GameServerBackup) error {
+	logger := log.FromContext(ctx)
+
+	pvc, err := buildRestorePvc(gs, gameDef, backup)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(gs, pvc, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, pvc); err != nil && !apierrors.IsAlreadyExists(err) {
+		logger.Error(err, "Failed to create restore pvc")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcCreateFailed", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *GameServerReconciler) deleteRestorePvc(ctx context.Context, gs *v1alpha1.GameServer) error {
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: restorePvcName(gs), Namespace: gs.Namespace}}
+	if err := r.Delete(ctx, pvc); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "Failed to delete restore pvc")
+		return err
+	}
+	return nil
+}
+
+// restoreRequestedAt returns the time of a restore request, the zero time if it has none.
+func restoreRequestedAt(t *metav1.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
+
+// restoreKey identifies a restore request on the restore Pod.
+func restoreKey(source *v1alpha1.RestoreSource) string {
+	if source.RequestedAt == nil {
+		return source.Backup
+	}
+	return fmt.Sprintf("%s@%d", source.Backup, source.RequestedAt.Unix())
+}
+
+func restorePvcName(gs *v1alpha1.GameServer) string {
+	return fmt.Sprintf("gs-%s-restore", ResolveGameServerId(gs))
+}
+
+// buildRestorePvc returns a claim provisioned from the VolumeSnapshot of the backup, at least as large as
+// the snapshot.
+func buildRestorePvc(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition, backup *v1alpha1.GameServerBackup) (*corev1.PersistentVolumeClaim, error) {
+	size, err := resolveVolumeSize(gs, gameDef)
+	if err != nil {
+		return nil, err
+	}
+	if restoreSize := resource.NewQuantity(backup.Status.SizeBytes, resource.BinarySI); restoreSize.Cmp(size) > 0 {
+		size = *restoreSize
+	}
+	pvc := buildPvc(gs, gameDef, restorePvcName(gs), size)
+	pvc.Annotations = nil
+	pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	apiGroup := volumeSnapshotGVK.Group
+	pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     volumeSnapshotGVK.Kind,
+		Name:     backup.Status.Location,
+	}
+	return pvc, nil
+}
+
+// addRestoreContainers prepends the init containers restoring the backup to the Pod. The spec hash annotation
+// is left alone, the Pod counts as up to date once the restore is done.
+func addRestoreContainers(pod *corev1.Pod, gs *v1alpha1.GameServer, backup *v1alpha1.GameServerBackup, key, image, s3Image string) {
+	restore := corev1.Container{
+		Name:         restoreContainerName,
+		Image:        image,
+		Command:      []string{"sh", "-c", restoreScript},
+		VolumeMounts: []corev1.VolumeMount{{Name: "game-data", MountPath: "/data"}},
+	}
+	env := []corev1.EnvVar{{Name: "RESTORE_KEY", Value: key}}
+	var initContainers []corev1.Container
+	switch {
+	case backup.Spec.Target.VolumeSnapshot != nil:
+		restore.VolumeMounts = append(restore.VolumeMounts, corev1.VolumeMount{Name: "restore-source", MountPath: "/restore/source", ReadOnly: true})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "restore-source",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: restorePvcName(gs),
+				ReadOnly:  true,
+			}},
+		})
+	case backup.Spec.Target.PVC != nil:
+		env = append(env,
+			corev1.EnvVar{Name: "ARCHIVE", Value: "/restore/backup/" + backupArchivePath(backup)},
+			corev1.EnvVar{Name: "CHECKSUM", Value: backup.Status.Checksum},
+		)
+		restore.VolumeMounts = append(restore.VolumeMounts, corev1.VolumeMount{Name: "restore-backup", MountPath: "/restore/backup", ReadOnly: true})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "restore-backup",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: backup.Spec.Target.PVC.ClaimName,
+				ReadOnly:  true,
+			}},
+		})
+	case backup.Spec.Target.S3 != nil:
+		download := s3Container(backup, restoreDownloadContainerName, s3Image, restoreDownloadScript)
+		download.Env = append(download.Env, corev1.EnvVar{Name: "RESTORE_KEY", Value: key})
+		download.VolumeMounts = []corev1.VolumeMount{
+			{Name: "game-data", MountPath: "/data", ReadOnly: true},
+			{Name: "restore-work", MountPath: "/restore"},
+		}
+		initContainers = append(initContainers, download)
+		env = append(env,
+			corev1.EnvVar{Name: "ARCHIVE", Value: "/restore/backup.tar.gz"},
+			corev1.EnvVar{Name: "CHECKSUM", Value: backup.Status.Checksum},
+		)
+		restore.VolumeMounts = append(restore.VolumeMounts, corev1.VolumeMount{Name: "restore-work", MountPath: "/restore"})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name:         "restore-work",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+	}
+	restore.Env = env
+	pod.Spec.InitContainers = append(append(initContainers, restore), pod.Spec.InitContainers...)
+	pod.Annotations[restoreAnnotation] = key
+}
+
+// restoreProgress reports whether the restore init containers of the Pod finished, or why they failed.
+func restoreProgress(pod *corev1.Pod) (bool, string) {
+	done, failure, _ := initContainerProgress(pod, restoreContainerName, restoreDownloadContainerName)
+	return done, failure
+}
+
+// initContainerProgress reports whether the init container last finished, or why one of last and before
+// failed. The termination message of last is returned once it is done. An earlier failure of a container
+// only counts until a retry of it succeeded.
+func initContainerProgress(pod *corev1.Pod, last string, before ...string) (bool, string, string) {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != last && !slices.Contains(before, status.Name) {
+			continue
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode == 0 {
+			if status.Name == last {
+				return true, "", strings.TrimSpace(terminated.Message)
+			}
+			continue
+		}
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated != nil && terminated.ExitCode != 0 {
+				message := terminated.Message
+				if message == "" {
+					message = fmt.Sprintf("exited with code %d", terminated.ExitCode)
+				}
+				return false, fmt.Sprintf("init container %s %s", status.Name, message), ""
+			}
+		}
+	}
+	return false, "", ""
+}
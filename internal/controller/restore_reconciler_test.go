@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Restore", func() {
+	var (
+		ctx        = context.Background()
+		reconciler *GameServerReconciler
+		gs         *kraftnetescomv1alpha1.GameServer
+		gameDef    *kraftnetescomv1alpha1.GameDefinition
+		backup     *kraftnetescomv1alpha1.GameServerBackup
+		podKey     = types.NamespacedName{Name: "gs-staging-pod", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "default", UID: "uid-staging"},
+			Spec: kraftnetescomv1alpha1.GameServerSpec{
+				Game:        "minecraft",
+				RestoreFrom: &kraftnetescomv1alpha1.RestoreSource{Backup: "production-nightly"},
+			},
+		}
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			Game:    "minecraft",
+			Image:   "itzg/minecraft-server",
+			Storage: &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromBool(true), DefaultSize: "5Gi"},
+		}}
+		backup = &kraftnetescomv1alpha1.GameServerBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "production-nightly", Namespace: "default"},
+			Spec: kraftnetescomv1alpha1.GameServerBackupSpec{
+				GameServer: "production",
+				Target:     kraftnetescomv1alpha1.BackupTarget{PVC: &kraftnetescomv1alpha1.PVCBackupTarget{ClaimName: "backups"}},
+			},
+			Status: kraftnetescomv1alpha1.GameServerBackupStatus{
+				Phase:        kraftnetescomv1alpha1.BackupCompleted,
+				GameServerID: "production",
+				Checksum:     "sha256:beef",
+			},
+		}
+	})
+
+	setup := func(objects ...client.Object) {
+		reconciler, _ = newFakeGameServerReconciler(append(objects, gs, backup)...)
+	}
+
+	It("seeds a new GameServer with a Pod restoring the backup first", func() {
+		setup()
+		res, err := reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(gs.Status.LastRestore.Result).To(Equal(kraftnetescomv1alpha1.RestoreInProgress))
+
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.Annotations).To(HaveKeyWithValue(restoreAnnotation, "production-nightly"))
+		regular, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Annotations[podSpecHashAnnotation]).To(Equal(regular.Annotations[podSpecHashAnnotation]))
+
+		restore := pod.Spec.InitContainers[0]
+		Expect(restore.Name).To(Equal(restoreContainerName))
+		Expect(restore.Env).To(ContainElements(
+			corev1.EnvVar{Name: "ARCHIVE", Value: "/restore/backup/default/production/production-nightly.tar.gz"},
+			corev1.EnvVar{Name: "CHECKSUM", Value: "sha256:beef"},
+		))
+
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:  restoreContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		res, err = reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsZero()).To(BeTrue())
+		Expect(gs.Status.LastRestore.Result).To(Equal(kraftnetescomv1alpha1.RestoreSucceeded))
+
+		// The same request isn't carried out again.
+		res, err = reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsZero()).To(BeTrue())
+	})
+
+	It("stops the running game before restoring", func() {
+		running, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		setup(running)
+
+		_, err = reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apierrors.IsNotFound(reconciler.Get(ctx, podKey, &corev1.Pod{}))).To(BeTrue())
+
+		_, err = reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.Annotations).To(HaveKey(restoreAnnotation))
+	})
+
+	It("keeps the old data when the restore fails", func() {
+		setup()
+		_, err := reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:                 restoreContainerName,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+
+		_, err = reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gs.Status.LastRestore.Result).To(Equal(kraftnetescomv1alpha1.RestoreFailed))
+		Expect(gs.Status.LastRestore.Message).To(ContainSubstring("exited with code 1"))
+		Expect(apierrors.IsNotFound(reconciler.Get(ctx, podKey, &corev1.Pod{}))).To(BeTrue())
+	})
+
+	It("succeeds when a retry of the restore succeeded", func() {
+		setup()
+		_, err := reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:                 restoreContainerName,
+			State:                corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+
+		_, err = reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gs.Status.LastRestore.Result).To(Equal(kraftnetescomv1alpha1.RestoreSucceeded))
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+	})
+
+	It("fails for a missing backup", func() {
+		gs.Spec.RestoreFrom.Backup = "missing"
+		setup()
+		_, err := reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gs.Status.LastRestore.Result).To(Equal(kraftnetescomv1alpha1.RestoreFailed))
+	})
+
+	It("restores VolumeSnapshots through a claim provisioned from the snapshot", func() {
+		backup.Spec.Target = kraftnetescomv1alpha1.BackupTarget{VolumeSnapshot: &kraftnetescomv1alpha1.VolumeSnapshotBackupTarget{}}
+		backup.Status.Location = "production-nightly"
+		backup.Status.SizeBytes = 8 << 30
+		setup()
+		_, err := reconciler.reconcileRestore(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "gs-staging-restore", Namespace: "default"}, pvc)).To(Succeed())
+		Expect(pvc.Spec.DataSource.Kind).To(Equal("VolumeSnapshot"))
+		Expect(pvc.Spec.DataSource.Name).To(Equal("production-nightly"))
+		Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("8Gi"))
+		Expect(metav1.IsControlledBy(pvc, gs)).To(BeTrue())
+
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.Spec.InitContainers[0].VolumeMounts).To(ContainElement(
+			corev1.VolumeMount{Name: "restore-source", MountPath: "/restore/source", ReadOnly: true}))
+	})
+
+	It("downloads S3 backups before restoring them", func() {
+		backup.Spec.Target = kraftnetescomv1alpha1.BackupTarget{S3: &kraftnetescomv1alpha1.S3BackupTarget{
+			Endpoint: "http://minio.minio:9000", Bucket: "worlds", CredentialsSecret: "minio-credentials",
+		}}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+		addRestoreContainers(pod, gs, backup, "production-nightly", DefaultBackupImage, DefaultBackupS3Image)
+		Expect(pod.Spec.InitContainers).To(HaveLen(2))
+		Expect(pod.Spec.InitContainers[0].Name).To(Equal(restoreDownloadContainerName))
+		Expect(pod.Spec.InitContainers[0].Env).To(ContainElement(corev1.EnvVar{Name: "S3_KEY", Value: "default/production/production-nightly.tar.gz"}))
+		Expect(pod.Spec.InitContainers[1].Env).To(ContainElement(corev1.EnvVar{Name: "ARCHIVE", Value: "/restore/backup.tar.gz"}))
+	})
+})
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultSeedImage fills new game data from a seed and syncs mods. It needs sh, wget with TLS support, tar,
+	// gzip, unzip and sha256sum.
+	DefaultSeedImage = "alpine:3.20"
+	// seededAnnotation records on the game data claim when seeding finished. Seeded claims never get the seed
+	// container again.
+	seededAnnotation  = "kraftnetes.com/seeded-at"
+	seedContainerName = "seed"
+)
+
+// seedScript only fills empty game data, anything but a lost+found directory counts as a world to keep. The
+// seed is staged next to the data and moved in once complete, so a failed attempt leaves the data empty for
+// the next one.
+const seedScript = `set -e
+staging=/data/.kraftnetes-seed
+download=/data/.kraftnetes-seed-download
+rm -rf "$staging" "$download"
+if [ -n "$(ls -A /data | grep -v '^lost+found$')" ]; then
+  echo "Skipped seeding, the game data isn't empty" > /dev/termination-log
+  exit 0
+fi
+mkdir -p "$staging"
+archive="$SEED_ARCHIVE"
+if [ -n "$SEED_URL" ]; then
+  archive="$download"
+  wget -q -O "$archive" "$SEED_URL"
+  echo "$SEED_SHA256  $archive" | sha256sum -c -
+fi
+if [ -n "$archive" ]; then
+  if unzip -l "$archive" >/dev/null 2>&1; then
+    unzip -q "$archive" -d "$staging"
+  else
+    tar -xf "$archive" -C "$staging"
+  fi
+elif [ "$SEED_FILES" = "true" ]; then
+  find /seed -mindepth 1 -maxdepth 1 ! -name '..*' -exec cp -RL {} "$staging"/ \;
+else
+  cp -a "/seed/$SEED_PATH/." "$staging"
+fi
+rm -f "$download"
+find "$staging" -mindepth 1 -maxdepth 1 -exec mv {} /data/ \;
+rmdir "$staging"
+echo "Seeded the game data" > /dev/termination-log`
+
+// reconcileSeed records on the game data claim that the seed container of the Pod finished, whether it filled
+// the data or found it in use. Later Pods start without the seed container.
+func (r *GameServerReconciler) reconcileSeed(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if volumeClaimOptions(gs, gameDef).Seed == nil || !usesStorage(gs, gameDef) {
+		return ctrl.Result{}, nil
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("gs-%s-pvc", ResolveGameServerId(gs)), Namespace: gs.Namespace}, pvc); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to get pvc")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if _, seeded := pvc.Annotations[seededAnnotation]; seeded {
+		return ctrl.Result{}, nil
+	}
+
+	pod, err := getGamePod(ctx, r.Client, gs)
+	if err != nil || pod == nil {
+		return ctrl.Result{}, err
+	}
+	done, failure, message := initContainerProgress(pod, seedContainerName)
+	if failure != "" {
+		// The kubelet retries the init container, the data stays empty until it succeeds.
+		r.Recorder.Eventf(gs, corev1.EventTypeWarning, "SeedFailed", "Seeding the game data failed: %s", failure)
+		return ctrl.Result{}, nil
+	}
+	if !done {
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(pvc.DeepCopy())
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[seededAnnotation] = metaNow().UTC().Format(time.RFC3339)
+	if err := r.Patch(ctx, pvc, patch); err != nil {
+		logger.Error(err, "Failed to annotate pvc as seeded")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcUpdateFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+	if message == "" {
+		message = "Seeded the game data"
+	}
+	r.Recorder.Event(gs, corev1.EventTypeNormal, "Seeded", message)
+	return ctrl.Result{}, nil
+}
+
+// dataSeeded reports whether the game data claim was seeded already. Data in an emptyDir is seeded by every
+// new Pod.
+func (r *GameServerReconciler) dataSeeded(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (bool, error) {
+	if !usesStorage(gs, gameDef) {
+		return false, nil
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("gs-%s-pvc", ResolveGameServerId(gs)), Namespace: gs.Namespace}, pvc); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	_, seeded := pvc.Annotations[seededAnnotation]
+	return seeded, nil
+}
+
+// addSeedContainer prepends the init container filling the game data from the seed. Like the restore
+// containers it is left out of the spec hash, so the Pod stays current once the claim is seeded.
+func addSeedContainer(pod *corev1.Pod, seed *v1alpha1.SeedSource, image string) {
+	seedContainer := corev1.Container{
+		Name:         seedContainerName,
+		Image:        image,
+		Command:      []string{"sh", "-c", seedScript},
+		VolumeMounts: []corev1.VolumeMount{{Name: "game-data", MountPath: "/data"}},
+	}
+
+	var source *corev1.VolumeSource
+	var object *v1alpha1.ObjectSeedSource
+	switch {
+	case seed.HTTP != nil:
+		seedContainer.Env = []corev1.EnvVar{
+			{Name: "SEED_URL", Value: seed.HTTP.URL},
+			{Name: "SEED_SHA256", Value: seed.HTTP.SHA256},
+		}
+	case seed.ConfigMap != nil:
+		object = seed.ConfigMap
+		source = &corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: seed.ConfigMap.Name},
+		}}
+	case seed.Secret != nil:
+		object = seed.Secret
+		source = &corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: seed.Secret.Name}}
+	case seed.PVC != nil:
+		seedContainer.Env = []corev1.EnvVar{{Name: "SEED_PATH", Value: seed.PVC.Path}}
+		source = &corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: seed.PVC.ClaimName,
+			ReadOnly:  true,
+		}}
+	}
+	if object != nil {
+		if object.Key != "" {
+			seedContainer.Env = []corev1.EnvVar{{Name: "SEED_ARCHIVE", Value: path.Join("/seed", object.Key)}}
+		} else {
+			seedContainer.Env = []corev1.EnvVar{{Name: "SEED_FILES", Value: "true"}}
+		}
+	}
+	if source != nil {
+		seedContainer.VolumeMounts = append(seedContainer.VolumeMounts, corev1.VolumeMount{Name: "seed-source", MountPath: "/seed", ReadOnly: true})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: "seed-source", VolumeSource: *source})
+	}
+	pod.Spec.InitContainers = append([]corev1.Container{seedContainer}, pod.Spec.InitContainers...)
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Seed", func() {
+	var (
+		ctx        = context.Background()
+		reconciler *GameServerReconciler
+		recorder   *record.FakeRecorder
+		gs         *kraftnetescomv1alpha1.GameServer
+		gameDef    *kraftnetescomv1alpha1.GameDefinition
+		podKey     = types.NamespacedName{Name: "gs-survival-pod", Namespace: "default"}
+		pvcKey     = types.NamespacedName{Name: "gs-survival-pvc", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default", UID: "uid-survival"},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft"},
+		}
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			Game:  "minecraft",
+			Image: "itzg/minecraft-server",
+			Storage: &kraftnetescomv1alpha1.StorageConfig{
+				Enabled: kraftnetescomv1alpha1.FromBool(true),
+				VolumeClaimOptions: kraftnetescomv1alpha1.VolumeClaimOptions{Seed: &kraftnetescomv1alpha1.SeedSource{
+					HTTP: &kraftnetescomv1alpha1.HTTPSeedSource{
+						URL:    "https://example.com/world.tar.gz",
+						SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+					},
+				}},
+			},
+		}}
+
+		reconciler, recorder = newFakeGameServerReconciler(gs,
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: pvcKey.Name, Namespace: "default"}})
+	})
+
+	It("seeds the claim from the first Pod only", func() {
+		_, err := reconciler.reconcilePod(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("PodCreated")))
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.Spec.InitContainers[0].Name).To(Equal(seedContainerName))
+		Expect(pod.Spec.InitContainers[0].Env).To(ContainElement(corev1.EnvVar{Name: "SEED_URL", Value: "https://example.com/world.tar.gz"}))
+		regular, err := buildPod(gs, gameDef, DefaultSeedImage, logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Annotations[podSpecHashAnnotation]).To(Equal(regular.Annotations[podSpecHashAnnotation]))
+
+		// Nothing is recorded while the seed is running.
+		_, err = reconciler.reconcileSeed(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, pvcKey, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(seededAnnotation))
+
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:  seedContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "Seeded the game data\n"}},
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		_, err = reconciler.reconcileSeed(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, pvcKey, pvc)).To(Succeed())
+		Expect(pvc.Annotations).To(HaveKey(seededAnnotation))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Seeded Seeded the game data")))
+
+		// A replacement Pod keeps the world.
+		Expect(reconciler.Delete(ctx, pod)).To(Succeed())
+		_, err = reconciler.reconcilePod(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.Spec.InitContainers).To(BeEmpty())
+	})
+
+	It("reports a failing seed without marking the claim", func() {
+		_, err := reconciler.reconcilePod(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("PodCreated")))
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:                 seedContainerName,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+
+		_, err = reconciler.reconcileSeed(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, pvcKey, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(seededAnnotation))
+		Expect(recorder.Events).To(Receive(ContainSubstring("SeedFailed")))
+	})
+
+	It("marks the claim once a retry of the seed succeeded", func() {
+		_, err := reconciler.reconcilePod(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("PodCreated")))
+		pod := &corev1.Pod{}
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:                 seedContainerName,
+			State:                corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "Seeded the game data\n"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+
+		_, err = reconciler.reconcileSeed(ctx, gs, gameDef)
+		Expect(err).NotTo(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, pvcKey, pvc)).To(Succeed())
+		Expect(pvc.Annotations).To(HaveKey(seededAnnotation))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Seeded Seeded the game data")))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("lets a GameServer bring its own seed", func() {
+		gs.Spec.Storage = &kraftnetescomv1alpha1.VolumeClaimOptions{Seed: &kraftnetescomv1alpha1.SeedSource{
+			PVC: &kraftnetescomv1alpha1.PVCSeedSource{ClaimName: "gs-production-pvc", Path: "world"},
+		}}
+		pod := &corev1.Pod{}
+		addSeedContainer(pod, volumeClaimOptions(gs, gameDef).Seed, DefaultSeedImage)
+		Expect(pod.Spec.InitContainers[0].Env).To(ConsistOf(corev1.EnvVar{Name: "SEED_PATH", Value: "world"}))
+		Expect(pod.Spec.Volumes).To(ConsistOf(corev1.Volume{
+			Name: "seed-source",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: "gs-production-pvc",
+				ReadOnly:  true,
+			}},
+		}))
+	})
+
+	It("copies ConfigMap and Secret keys as files or extracts one as an archive", func() {
+		pod := &corev1.Pod{}
+		addSeedContainer(pod, &kraftnetescomv1alpha1.SeedSource{
+			ConfigMap: &kraftnetescomv1alpha1.ObjectSeedSource{Name: "lobby", Key: "lobby.zip"},
+		}, DefaultSeedImage)
+		Expect(pod.Spec.InitContainers[0].Env).To(ConsistOf(corev1.EnvVar{Name: "SEED_ARCHIVE", Value: "/seed/lobby.zip"}))
+		Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("lobby"))
+
+		pod = &corev1.Pod{}
+		addSeedContainer(pod, &kraftnetescomv1alpha1.SeedSource{
+			Secret: &kraftnetescomv1alpha1.ObjectSeedSource{Name: "whitelist"},
+		}, DefaultSeedImage)
+		Expect(pod.Spec.InitContainers[0].Env).To(ConsistOf(corev1.EnvVar{Name: "SEED_FILES", Value: "true"}))
+		Expect(pod.Spec.Volumes[0].Secret.SecretName).To(Equal("whitelist"))
+	})
+})
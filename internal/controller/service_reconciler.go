@@ -6,6 +6,7 @@ import (
 
 	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -19,29 +20,34 @@ func (r *GameServerReconciler) reconcileService(ctx context.Context, gs *v1alpha
 
 	logger := log.FromContext(ctx)
 
-	fileBrowserEnabled := gameDef.Spec.FileBrowser.BoolVal
-	if gs.Spec.Filebrowser != nil {
-		fileBrowserEnabled = *gs.Spec.Filebrowser
-	}
-	if !fileBrowserEnabled {
-		logger.Info("File browser disabled. Skipping creation of filebrowser service.", "gameName", gameDef.Name)
-		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "SkippedFileBrowserService", "Filebrowser service is disabled for %s", gs.Name)
-		return ctrl.Result{}, nil
-	}
-
 	id := ResolveGameServerId(gs)
 	serviceName := fmt.Sprintf("gs-%s-filebrowser-service", id)
 
-	service := &corev1.Service{}
-	if err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: gs.Namespace}, service); err == nil {
-		return ctrl.Result{}, nil
-	} else if client.IgnoreNotFound(err) != nil {
+	existing := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: gs.Namespace}, existing); client.IgnoreNotFound(err) != nil {
 		logger.Error(err, "Failed to get Service")
 		r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceLookupFailed", err.Error())
 		return ctrl.Result{}, err
+	} else if err != nil {
+		existing = nil
 	}
 
-	service = &corev1.Service{
+	if !fileBrowserEnabled(gs, gameDef) {
+		if existing != nil {
+			if err := r.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "Failed to delete Service")
+				r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceDeleteFailed", err.Error())
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(gs, corev1.EventTypeNormal, "ServiceDeleted", "Deleted Service %s, filebrowser is disabled", existing.Name)
+			return ctrl.Result{}, nil
+		}
+		logger.Info("File browser disabled. Skipping creation of filebrowser service.", "gameName", gameDef.Name)
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "SkippedFileBrowserService", "Filebrowser service is disabled for %s", gs.Name)
+		return ctrl.Result{}, nil
+	}
+
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceName,
 			Namespace: gs.Namespace,
@@ -57,14 +63,30 @@ func (r *GameServerReconciler) reconcileService(ctx context.Context, gs *v1alpha
 			},
 			Ports: []corev1.ServicePort{{
 				Name:       "filebrowser",
-				Port:       8080,
-				TargetPort: intstr.FromInt(8077),
+				Port:       fileBrowserServicePort,
+				TargetPort: intstr.FromInt(fileBrowserPort),
 				Protocol:   corev1.ProtocolTCP,
 			}},
 			Type: corev1.ServiceTypeClusterIP,
 		},
 	}
 
+	if existing != nil {
+		if equality.Semantic.DeepEqual(existing.Spec.Ports, service.Spec.Ports) &&
+			equality.Semantic.DeepEqual(existing.Spec.Selector, service.Spec.Selector) {
+			return ctrl.Result{}, nil
+		}
+		existing.Spec.Ports = service.Spec.Ports
+		existing.Spec.Selector = service.Spec.Selector
+		if err := r.Update(ctx, existing); err != nil {
+			logger.Error(err, "Failed to update Service")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "ServiceUpdateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "ServiceUpdated", "Updated Service %s", existing.Name)
+		return ctrl.Result{}, nil
+	}
+
 	if err := controllerutil.SetControllerReference(gs, service, r.Scheme); err != nil {
 		r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
 		return ctrl.Result{}, err
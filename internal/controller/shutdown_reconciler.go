@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// gameServerFinalizer keeps the GameServer around until its Pod was stopped gracefully.
+	gameServerFinalizer = "kraftnetes.com/graceful-shutdown"
+	// stopRequestedAtAnnotation records on the Pod when the stop strategy was triggered.
+	stopRequestedAtAnnotation = "kraftnetes.com/stop-requested-at"
+	// stopRestartCountAnnotation records the game container's restart count at the time of the stop request,
+	// so a container that exited and got restarted by the kubelet is still recognised as stopped.
+	stopRestartCountAnnotation = "kraftnetes.com/stop-restart-count"
+
+	defaultShutdownGracePeriod = 30 * time.Second
+	stopPollInterval           = 5 * time.Second
+)
+
+// reconcileDelete runs the stop strategy against the GameServer's Pod, waits for the game to exit
+// (or the grace period to run out), deletes the Pod and waits until it is gone, applies the storage
+// RetainPolicy and finally releases the finalizer.
+func (r *GameServerReconciler) reconcileDelete(ctx context.Context, gs *v1alpha1.GameServer) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(gs, gameServerFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	id := ResolveGameServerId(gs)
+	podName := fmt.Sprintf("gs-%s-pod", id)
+
+	pod, err := r.getExistingPod(ctx, podName, gs.Namespace)
+	if err != nil && client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to get Pod")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PodLookupFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	if pod != nil {
+		if err := r.setState(ctx, gs, v1alpha1.GameServerStopping, "GameServer is being deleted, stopping the game"); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		stopped, requeueAfter, err := r.stopPod(ctx, gs, pod, r.resolveStopStrategy(ctx, gs))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !stopped {
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to delete Pod")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "PodDeleteFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		// The claim is only released or snapshotted once the Pod is gone and the game stopped writing to it.
+		return ctrl.Result{RequeueAfter: stopPollInterval}, nil
+	}
+
+	done, requeueAfter, err := r.retainStorage(ctx, gs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	controllerutil.RemoveFinalizer(gs, gameServerFinalizer)
+	if err := r.Update(ctx, gs); err != nil {
+		logger.Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// resolveStopStrategy returns the effective StopStrategy of the GameServer, or nil if it can't be determined.
+// Deletion must not get stuck on a broken or missing GameDefinition, so errors only fall back to a plain delete.
+func (r *GameServerReconciler) resolveStopStrategy(ctx context.Context, gs *v1alpha1.GameServer) *v1alpha1.StopStrategy {
+	mergedConfig, err := resolveMergedConfig(ctx, r.Client, gs)
+	if err != nil {
+		log.FromContext(ctx).Info("Stopping without stop strategy", "error", err.Error())
+		return nil
+	}
+	return mergedConfig.StopStrategy
+}
+
+// resolveMergedConfig fetches the GameServer's GameDefinition, renders it for the GameServer and merges its
+// profile, for the code paths that run outside the main reconcile.
+func resolveMergedConfig(ctx context.Context, c client.Client, gs *v1alpha1.GameServer) (v1alpha1.GameDefinitionSpec, error) {
+	gameDef := &v1alpha1.GameDefinition{}
+	if err := c.Get(ctx, types.NamespacedName{Name: gs.Spec.Game}, gameDef); err != nil {
+		return v1alpha1.GameDefinitionSpec{}, fmt.Errorf("GameDefinition %s unavailable: %w", gs.Spec.Game, err)
+	}
+	resolvedSpec, err := resolveGameDefinitionSpec(gs, gameDef)
+	if err != nil {
+		return v1alpha1.GameDefinitionSpec{}, fmt.Errorf("failed to resolve GameDefinition %s: %w", gs.Spec.Game, err)
+	}
+	gameDef.Spec = resolvedSpec
+
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	return mergedConfig, nil
+}
+
+// stopPod triggers the stop strategy once and then reports whether the game has stopped.
+// While the game is still shutting down it returns the duration after which to check again.
+func (r *GameServerReconciler) stopPod(ctx context.Context, gs *v1alpha1.GameServer, pod *corev1.Pod, strategy *v1alpha1.StopStrategy) (bool, time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	if !pod.DeletionTimestamp.IsZero() || pod.Status.Phase != corev1.PodRunning {
+		return true, 0, nil
+	}
+	if strategy == nil || (strategy.Stdin == "" && len(strategy.Cmd) == 0) || r.Executor == nil {
+		return true, 0, nil
+	}
+
+	gracePeriod, err := shutdownGracePeriod(strategy)
+	if err != nil {
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "InvalidShutdownGracePeriod", err.Error())
+	}
+
+	requestedAt, ok := pod.Annotations[stopRequestedAtAnnotation]
+	if !ok {
+		if err := r.triggerStop(ctx, pod, strategy); err != nil {
+			// A game that can't be asked to stop must not block the deletion forever.
+			logger.Error(err, "Failed to run stop strategy")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "StopStrategyFailed", err.Error())
+			return true, 0, nil
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[stopRequestedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		pod.Annotations[stopRestartCountAnnotation] = strconv.Itoa(int(gameContainerRestartCount(pod)))
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			logger.Error(err, "Failed to annotate Pod with stop request")
+			return false, 0, err
+		}
+
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "StopRequested",
+			"Requested graceful stop of Pod %s, waiting up to %s", pod.Name, gracePeriod)
+		return false, min(stopPollInterval, gracePeriod), nil
+	}
+
+	restartCount, _ := strconv.Atoi(pod.Annotations[stopRestartCountAnnotation])
+	if gameContainerStopped(pod, int32(restartCount)) {
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "GameStopped", "Game in Pod %s stopped gracefully", pod.Name)
+		return true, 0, nil
+	}
+
+	since, err := time.Parse(time.RFC3339, requestedAt)
+	if err != nil {
+		return true, 0, nil
+	}
+	remaining := gracePeriod - time.Since(since)
+	if remaining <= 0 {
+		r.Recorder.Eventf(gs, corev1.EventTypeWarning, "ShutdownGracePeriodExceeded",
+			"Game in Pod %s did not stop within %s", pod.Name, gracePeriod)
+		return true, 0, nil
+	}
+	return false, min(stopPollInterval, remaining), nil
+}
+
+// triggerStop sends the configured stdin line to the game container, or runs the stop command in it.
+func (r *GameServerReconciler) triggerStop(ctx context.Context, pod *corev1.Pod, strategy *v1alpha1.StopStrategy) error {
+	if strategy.Stdin != "" {
+		return r.Executor.SendStdin(ctx, pod, gameContainerName, strategy.Stdin)
+	}
+	return r.Executor.Exec(ctx, pod, gameContainerName, strategy.Cmd)
+}
+
+// shutdownGracePeriod parses the grace period of the strategy, falling back to the default when unset or invalid.
+func shutdownGracePeriod(strategy *v1alpha1.StopStrategy) (time.Duration, error) {
+	if strategy == nil || strategy.ShutdownGracePeriod == "" {
+		return defaultShutdownGracePeriod, nil
+	}
+	d, err := time.ParseDuration(strategy.ShutdownGracePeriod)
+	if err != nil || d <= 0 {
+		return defaultShutdownGracePeriod, fmt.Errorf("invalid shutdownGracePeriod %q, using %s", strategy.ShutdownGracePeriod, defaultShutdownGracePeriod)
+	}
+	return d, nil
+}
+
+// gameContainerStatus returns the status of the game container, or nil if the kubelet hasn't reported it yet.
+func gameContainerStatus(pod *corev1.Pod) *corev1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == gameContainerName {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+func gameContainerRestartCount(pod *corev1.Pod) int32 {
+	if status := gameContainerStatus(pod); status != nil {
+		return status.RestartCount
+	}
+	return 0
+}
+
+// gameContainerStopped reports whether the game container is no longer running, or was restarted
+// by the kubelet since the stop request was sent.
+func gameContainerStopped(pod *corev1.Pod, restartCountAtStop int32) bool {
+	status := gameContainerStatus(pod)
+	if status == nil {
+		return true
+	}
+	return status.State.Running == nil || status.RestartCount > restartCountAtStop
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Graceful shutdown", func() {
+	var (
+		ctx        = context.Background()
+		reconciler *GameServerReconciler
+		recorder   *record.FakeRecorder
+		executor   *fakePodExecutor
+		gs         *kraftnetescomv1alpha1.GameServer
+		pod        *corev1.Pod
+		podKey     = types.NamespacedName{Name: "gs-abc123-pod", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default", Labels: map[string]string{"kraftnetes-id": "abc123"}},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft"},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: podKey.Name, Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  gameContainerName,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+		executor = &fakePodExecutor{}
+	})
+
+	setup := func(objects ...client.Object) {
+		reconciler, recorder = newFakeGameServerReconciler(append(objects, gs, pod)...)
+		reconciler.Executor = executor
+	}
+
+	stop := func(strategy *kraftnetescomv1alpha1.StopStrategy) (bool, time.Duration) {
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		stopped, requeueAfter, err := reconciler.stopPod(ctx, gs, pod, strategy)
+		Expect(err).NotTo(HaveOccurred())
+		return stopped, requeueAfter
+	}
+
+	It("sends the stop line to stdin once and records the request on the Pod", func() {
+		setup()
+		stopped, requeueAfter := stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop", ShutdownGracePeriod: "2s"})
+		Expect(stopped).To(BeFalse())
+		Expect(requeueAfter).To(Equal(2 * time.Second))
+		Expect(executor.stdin).To(Equal([]string{"stop"}))
+		Expect(executor.commands).To(BeEmpty())
+		Expect(pod.Annotations).To(HaveKey(stopRequestedAtAnnotation))
+		Expect(pod.Annotations).To(HaveKeyWithValue(stopRestartCountAnnotation, "0"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("StopRequested")))
+
+		// Later passes only wait for the game to exit.
+		stopped, requeueAfter = stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop", ShutdownGracePeriod: "2s"})
+		Expect(stopped).To(BeFalse())
+		Expect(requeueAfter).To(BeNumerically("<=", 2*time.Second))
+		Expect(executor.stdin).To(HaveLen(1))
+	})
+
+	It("runs the stop command if there is no stdin line", func() {
+		setup()
+		stopped, requeueAfter := stop(&kraftnetescomv1alpha1.StopStrategy{Cmd: []string{"rcon-cli", "stop"}})
+		Expect(stopped).To(BeFalse())
+		Expect(requeueAfter).To(Equal(stopPollInterval))
+		Expect(executor.commands).To(Equal([][]string{{"rcon-cli", "stop"}}))
+		Expect(executor.stdin).To(BeEmpty())
+	})
+
+	It("gives up waiting once the grace period ran out", func() {
+		pod.Annotations = map[string]string{
+			stopRequestedAtAnnotation:  time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+			stopRestartCountAnnotation: "0",
+		}
+		setup()
+		stopped, _ := stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop", ShutdownGracePeriod: "30s"})
+		Expect(stopped).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("ShutdownGracePeriodExceeded")))
+		Expect(executor.stdin).To(BeEmpty())
+	})
+
+	It("falls back to the default grace period for an invalid duration", func() {
+		gracePeriod, err := shutdownGracePeriod(&kraftnetescomv1alpha1.StopStrategy{ShutdownGracePeriod: "soon"})
+		Expect(err).To(HaveOccurred())
+		Expect(gracePeriod).To(Equal(defaultShutdownGracePeriod))
+		gracePeriod, err = shutdownGracePeriod(&kraftnetescomv1alpha1.StopStrategy{ShutdownGracePeriod: "-5s"})
+		Expect(err).To(HaveOccurred())
+		Expect(gracePeriod).To(Equal(defaultShutdownGracePeriod))
+
+		setup()
+		stopped, requeueAfter := stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop", ShutdownGracePeriod: "soon"})
+		Expect(stopped).To(BeFalse())
+		Expect(requeueAfter).To(Equal(stopPollInterval))
+		Expect(recorder.Events).To(Receive(ContainSubstring("InvalidShutdownGracePeriod")))
+	})
+
+	It("counts a game container restarted by the kubelet as stopped", func() {
+		pod.Annotations = map[string]string{
+			stopRequestedAtAnnotation:  time.Now().UTC().Format(time.RFC3339),
+			stopRestartCountAnnotation: "2",
+		}
+		pod.Status.ContainerStatuses[0].RestartCount = 2
+		setup()
+		stopped, _ := stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop"})
+		Expect(stopped).To(BeFalse())
+
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.ContainerStatuses[0].RestartCount = 3
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		stopped, _ = stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop"})
+		Expect(stopped).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("GameStopped")))
+	})
+
+	It("doesn't let a failing executor block the stop", func() {
+		executor.err = errors.New("container not found")
+		setup()
+		stopped, _ := stop(&kraftnetescomv1alpha1.StopStrategy{Stdin: "stop"})
+		Expect(stopped).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("StopStrategyFailed")))
+		Expect(pod.Annotations).NotTo(HaveKey(stopRequestedAtAnnotation))
+	})
+
+	It("removes the finalizer only once the Pod is gone", func() {
+		deletedAt := metav1.Now()
+		gs.DeletionTimestamp = &deletedAt
+		gs.Finalizers = []string{gameServerFinalizer}
+		pod.Finalizers = []string{"kraftnetes.com/test"}
+		setup(&kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:         "minecraft",
+				Image:        "itzg/minecraft-server",
+				StopStrategy: &kraftnetescomv1alpha1.StopStrategy{Stdin: "stop"},
+			},
+		})
+		gsKey := client.ObjectKeyFromObject(gs)
+
+		res, err := reconciler.reconcileDelete(ctx, gs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(stopPollInterval))
+		Expect(executor.stdin).To(Equal([]string{"stop"}))
+		Expect(gs.Status.State).To(Equal(kraftnetescomv1alpha1.GameServerStopping))
+
+		// The game exited, the Pod is deleted but still terminating.
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+		Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		for range 2 {
+			res, err = reconciler.reconcileDelete(ctx, gs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(stopPollInterval))
+			Expect(reconciler.Get(ctx, gsKey, gs)).To(Succeed())
+			Expect(gs.Finalizers).To(ContainElement(gameServerFinalizer))
+		}
+
+		Expect(reconciler.Get(ctx, podKey, pod)).To(Succeed())
+		Expect(pod.DeletionTimestamp).NotTo(BeNil())
+		pod.Finalizers = nil
+		Expect(reconciler.Update(ctx, pod)).To(Succeed())
+		res, err = reconciler.reconcileDelete(ctx, gs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsZero()).To(BeTrue())
+		Expect(apierrors.IsNotFound(reconciler.Get(ctx, gsKey, gs))).To(BeTrue())
+	})
+})
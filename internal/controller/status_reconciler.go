@@ -2,19 +2,68 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// updateStatus derives the GameServer state and conditions from its Pod and PVC and writes them if they changed.
 func (r *GameServerReconciler) updateStatus(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	id := ResolveGameServerId(gs)
+
+	pod, err := r.getExistingPod(ctx, fmt.Sprintf("gs-%s-pod", id), gs.Namespace)
+	if err != nil && client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to get Pod")
+		return ctrl.Result{}, err
+	}
+
+	storageEnabled := usesStorage(gs, gameDef)
+	var pvc *corev1.PersistentVolumeClaim
+	if storageEnabled {
+		pvc = &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("gs-%s-pvc", id), Namespace: gs.Namespace}, pvc)
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to get pvc")
+			return ctrl.Result{}, err
+		} else if err != nil {
+			pvc = nil
+		}
+	}
+
+	var node *corev1.Node
+	if pod != nil && pod.Spec.NodeName != "" {
+		node = &corev1.Node{}
+		if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to get Node", "node", pod.Spec.NodeName)
+			return ctrl.Result{}, err
+		} else if err != nil {
+			node = nil
+		}
+	}
 
 	desired := gs.DeepCopy()
-	desired.Status.State = "Running"
-	desired.Status.Message = "Pod is active"
+	setGameServerStatus(&desired.Status, gs, pod, pvc, storageEnabled)
+	if pvc != nil {
+		if size, err := resolveVolumeSize(gs, gameDef); err == nil {
+			if condition, ok := storageResizingCondition(gs.Status.Conditions, pvc, size); ok {
+				condition.ObservedGeneration = gs.Generation
+				meta.SetStatusCondition(&desired.Status.Conditions, condition)
+			}
+		}
+	}
+	mergedConfig, _, _ := resolveConfigEnvResources(gs, gameDef)
+	desired.Status.Endpoints = gameServerEndpoints(gs, mergedConfig.Ports, pod, node)
 
 	if reflect.DeepEqual(gs.Status, desired.Status) {
 		return ctrl.Result{}, nil // No update needed
@@ -25,7 +74,14 @@ func (r *GameServerReconciler) updateStatus(ctx context.Context, gs *v1alpha1.Ga
 		return ctrl.Result{}, err
 	}
 
-	r.Recorder.Event(gs, "Normal", "StatusUpdated", "Updated status to Running")
+	if gs.Status.State != desired.Status.State {
+		eventType := corev1.EventTypeNormal
+		if desired.Status.State == v1alpha1.GameServerFailed || desired.Status.State == v1alpha1.GameServerCrashLooping {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Eventf(gs, eventType, "StateChanged", "GameServer is %s: %s", desired.Status.State, desired.Status.Message)
+	}
+	gs.Status = desired.Status
 	return ctrl.Result{}, nil
 }
 
@@ -34,7 +90,7 @@ func (r *GameServerReconciler) reconcileInitialStatus(ctx context.Context, gs *v
 		return ctrl.Result{}, nil
 	}
 
-	gs.Status.State = "Pending"
+	gs.Status.State = v1alpha1.GameServerPending
 	gs.Status.Message = "GameServer is pending initialization"
 	if err := r.Status().Update(ctx, gs); err != nil {
 		return ctrl.Result{}, err
@@ -42,3 +98,216 @@ func (r *GameServerReconciler) reconcileInitialStatus(ctx context.Context, gs *v
 	r.Recorder.Event(gs, "Normal", "Initializing", "GameServer is pending initialization")
 	return ctrl.Result{}, nil
 }
+
+// setState writes state and message to the GameServer status if they changed.
+// It is used on paths that never reach updateStatus, such as a missing GameDefinition or deletion.
+func (r *GameServerReconciler) setState(ctx context.Context, gs *v1alpha1.GameServer, state v1alpha1.GameServerState, message string) error {
+	if gs.Status.State == state && gs.Status.Message == message {
+		return nil
+	}
+	gs.Status.State = state
+	gs.Status.Message = message
+	gs.Status.ObservedGeneration = gs.Generation
+	if err := r.Status().Update(ctx, gs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServer status")
+		return err
+	}
+	return nil
+}
+
+// setValidCondition records the outcome of ValidateGameServer in the Valid condition. Input errors name the
+// offending input in their field path. An invalid GameServer is also marked Failed, as nothing can be rendered for it.
+func (r *GameServerReconciler) setValidCondition(ctx context.Context, gs *v1alpha1.GameServer, errs field.ErrorList) error {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "GameServer is valid for its GameDefinition",
+		ObservedGeneration: gs.Generation,
+	}
+	if len(errs) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSpec"
+		if strings.HasPrefix(errs[0].Field, "spec.inputs") {
+			condition.Reason = "InvalidInput"
+		}
+		if errs[0].Field == "spec.volumeSize" || strings.HasPrefix(errs[0].Field, "spec.storage") {
+			condition.Reason = "InvalidStorage"
+		}
+		if strings.HasPrefix(errs[0].Field, "spec.backup") {
+			condition.Reason = "InvalidBackup"
+		}
+		if strings.HasPrefix(errs[0].Field, "spec.restoreFrom") {
+			condition.Reason = "InvalidRestore"
+		}
+		if strings.HasPrefix(errs[0].Field, "spec.mods") || strings.HasPrefix(errs[0].Field, "spec.removeMods") {
+			condition.Reason = "InvalidMods"
+		}
+		condition.Message = errs.ToAggregate().Error()
+	}
+
+	changed := meta.SetStatusCondition(&gs.Status.Conditions, condition)
+	if len(errs) > 0 && (gs.Status.State != v1alpha1.GameServerFailed || gs.Status.Message != condition.Message) {
+		gs.Status.State = v1alpha1.GameServerFailed
+		gs.Status.Message = condition.Message
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	gs.Status.ObservedGeneration = gs.Generation
+	if err := r.Status().Update(ctx, gs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update GameServer status")
+		return err
+	}
+	return nil
+}
+
+// setGameServerStatus fills state, message, observedGeneration and conditions from the observed Pod and PVC.
+// pod and pvc may be nil when they don't exist (yet).
+func setGameServerStatus(status *v1alpha1.GameServerStatus, gs *v1alpha1.GameServer, pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, storageEnabled bool) {
+	state, message := gameServerState(gs, pod, pvc, storageEnabled)
+	status.State = state
+	status.Message = message
+	status.ObservedGeneration = gs.Generation
+
+	for _, condition := range []metav1.Condition{
+		podScheduledCondition(pod),
+		storageBoundCondition(pvc, storageEnabled),
+		readyCondition(state, message),
+	} {
+		condition.ObservedGeneration = gs.Generation
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+}
+
+// gameServerState maps the observed Pod and PVC onto the GameServer lifecycle.
+func gameServerState(gs *v1alpha1.GameServer, pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, storageEnabled bool) (v1alpha1.GameServerState, string) {
+	if !gs.DeletionTimestamp.IsZero() {
+		if pod == nil {
+			return v1alpha1.GameServerStopped, "GameServer is being deleted"
+		}
+		return v1alpha1.GameServerStopping, "GameServer is being deleted, stopping the game"
+	}
+
+	if storageEnabled && (pvc == nil || pvc.Status.Phase != corev1.ClaimBound) && (pod == nil || pod.Status.Phase == corev1.PodPending) {
+		return v1alpha1.GameServerProvisioning, "Waiting for the game data volume to be bound"
+	}
+
+	if pod == nil {
+		return v1alpha1.GameServerPending, "Waiting for the Pod to be created"
+	}
+	if !pod.DeletionTimestamp.IsZero() {
+		return v1alpha1.GameServerStopping, fmt.Sprintf("Pod %s is terminating", pod.Name)
+	}
+	if _, ok := pod.Annotations[stopRequestedAtAnnotation]; ok {
+		return v1alpha1.GameServerStopping, "Waiting for the game to stop"
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return v1alpha1.GameServerStopped, "The game exited"
+	case corev1.PodFailed:
+		return v1alpha1.GameServerFailed, podMessage(pod, "The Pod failed")
+	case corev1.PodUnknown:
+		return v1alpha1.GameServerFailed, "The Pod status is unknown, its node may be unreachable"
+	}
+
+	if status := gameContainerStatus(pod); status != nil {
+		if waiting := status.State.Waiting; waiting != nil {
+			switch waiting.Reason {
+			case "CrashLoopBackOff":
+				return v1alpha1.GameServerCrashLooping, fmt.Sprintf("The game crashed %d times: %s", status.RestartCount, waiting.Message)
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError":
+				return v1alpha1.GameServerFailed, fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+			}
+		}
+		if status.State.Terminated != nil && status.RestartCount > 0 {
+			return v1alpha1.GameServerCrashLooping, fmt.Sprintf("The game crashed %d times: %s", status.RestartCount, status.State.Terminated.Reason)
+		}
+		if pod.Status.Phase == corev1.PodRunning && status.Ready {
+			return v1alpha1.GameServerReady, "The game server is running"
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		scheduled := podCondition(pod, corev1.PodScheduled)
+		if scheduled == nil {
+			return v1alpha1.GameServerPending, "Waiting for the Pod to be scheduled"
+		}
+		if scheduled.Status != corev1.ConditionTrue {
+			return v1alpha1.GameServerPending, fmt.Sprintf("The Pod can't be scheduled: %s", scheduled.Message)
+		}
+	}
+	return v1alpha1.GameServerStarting, "Waiting for the game container to become ready"
+}
+
+func podScheduledCondition(pod *corev1.Pod) metav1.Condition {
+	if pod == nil {
+		return metav1.Condition{Type: v1alpha1.ConditionPodScheduled, Status: metav1.ConditionFalse, Reason: "PodNotFound",
+			Message: "The Pod doesn't exist"}
+	}
+	scheduled := podCondition(pod, corev1.PodScheduled)
+	if scheduled == nil {
+		return metav1.Condition{Type: v1alpha1.ConditionPodScheduled, Status: metav1.ConditionUnknown, Reason: "Pending",
+			Message: "The scheduler hasn't picked up the Pod yet"}
+	}
+	condition := metav1.Condition{
+		Type:    v1alpha1.ConditionPodScheduled,
+		Status:  metav1.ConditionStatus(scheduled.Status),
+		Reason:  scheduled.Reason,
+		Message: scheduled.Message,
+	}
+	if condition.Reason == "" {
+		condition.Reason = "Scheduled"
+		if condition.Status != metav1.ConditionTrue {
+			condition.Reason = "Unschedulable"
+		}
+	}
+	return condition
+}
+
+func storageBoundCondition(pvc *corev1.PersistentVolumeClaim, storageEnabled bool) metav1.Condition {
+	switch {
+	case !storageEnabled:
+		return metav1.Condition{Type: v1alpha1.ConditionStorageBound, Status: metav1.ConditionTrue, Reason: "StorageDisabled",
+			Message: "The game doesn't use persistent storage"}
+	case pvc == nil:
+		return metav1.Condition{Type: v1alpha1.ConditionStorageBound, Status: metav1.ConditionFalse, Reason: "ClaimNotFound",
+			Message: "The PersistentVolumeClaim doesn't exist"}
+	case pvc.Status.Phase == corev1.ClaimBound:
+		return metav1.Condition{Type: v1alpha1.ConditionStorageBound, Status: metav1.ConditionTrue, Reason: "Bound",
+			Message: fmt.Sprintf("Bound to volume %s", pvc.Spec.VolumeName)}
+	default:
+		return metav1.Condition{Type: v1alpha1.ConditionStorageBound, Status: metav1.ConditionFalse, Reason: "Claim" + string(pvc.Status.Phase),
+			Message: fmt.Sprintf("The PersistentVolumeClaim %s is %s", pvc.Name, pvc.Status.Phase)}
+	}
+}
+
+func readyCondition(state v1alpha1.GameServerState, message string) metav1.Condition {
+	if state == v1alpha1.GameServerReady {
+		return metav1.Condition{Type: v1alpha1.ConditionReady, Status: metav1.ConditionTrue, Reason: string(state), Message: message}
+	}
+	return metav1.Condition{Type: v1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: string(state), Message: message}
+}
+
+// podCondition returns the Pod condition of the given type, or nil if it isn't set.
+func podCondition(pod *corev1.Pod, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// podMessage returns the Pod's status message, or fallback if the kubelet didn't leave one.
+func podMessage(pod *corev1.Pod, fallback string) string {
+	if pod.Status.Message != "" {
+		return pod.Status.Message
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	return fallback
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("GameServer status", func() {
+	var gs *kraftnetescomv1alpha1.GameServer
+
+	newPod := func(phase corev1.PodPhase, scheduled bool, containerStatus *corev1.ContainerStatus) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "gs-test-pod"},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		if scheduled {
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}}
+		}
+		if containerStatus != nil {
+			containerStatus.Name = gameContainerName
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{*containerStatus}
+		}
+		return pod
+	}
+	boundPvc := &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound}}
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 3}}
+	})
+
+	It("is Pending without a Pod", func() {
+		state, _ := gameServerState(gs, nil, nil, false)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerPending))
+	})
+
+	It("is Provisioning while the PVC is not bound", func() {
+		pvc := &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}}
+		state, _ := gameServerState(gs, newPod(corev1.PodPending, false, nil), pvc, true)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerProvisioning))
+	})
+
+	It("is Pending while the Pod is unschedulable", func() {
+		pod := newPod(corev1.PodPending, false, nil)
+		pod.Status.Conditions = []corev1.PodCondition{{
+			Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/1 nodes are available",
+		}}
+		state, message := gameServerState(gs, pod, boundPvc, true)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerPending))
+		Expect(message).To(ContainSubstring("0/1 nodes are available"))
+	})
+
+	It("is Failed when the image can't be pulled", func() {
+		pod := newPod(corev1.PodPending, true, &corev1.ContainerStatus{
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+		})
+		state, _ := gameServerState(gs, pod, nil, false)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerFailed))
+	})
+
+	It("is CrashLooping when the container is in back-off", func() {
+		pod := newPod(corev1.PodRunning, true, &corev1.ContainerStatus{
+			RestartCount: 4,
+			State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		})
+		state, _ := gameServerState(gs, pod, nil, false)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerCrashLooping))
+	})
+
+	It("is Starting until the game container is ready", func() {
+		pod := newPod(corev1.PodRunning, true, &corev1.ContainerStatus{
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+		state, _ := gameServerState(gs, pod, nil, false)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerStarting))
+	})
+
+	It("is Stopping once a graceful stop was requested", func() {
+		pod := newPod(corev1.PodRunning, true, &corev1.ContainerStatus{
+			Ready: true,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+		pod.Annotations = map[string]string{stopRequestedAtAnnotation: "2025-01-01T00:00:00Z"}
+		state, _ := gameServerState(gs, pod, nil, false)
+		Expect(state).To(Equal(kraftnetescomv1alpha1.GameServerStopping))
+	})
+
+	It("reports Ready with matching conditions and observedGeneration", func() {
+		pod := newPod(corev1.PodRunning, true, &corev1.ContainerStatus{
+			Ready: true,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+		status := &kraftnetescomv1alpha1.GameServerStatus{}
+		setGameServerStatus(status, gs, pod, boundPvc, true)
+
+		Expect(status.State).To(Equal(kraftnetescomv1alpha1.GameServerReady))
+		Expect(status.ObservedGeneration).To(Equal(int64(3)))
+		for _, conditionType := range []string{
+			kraftnetescomv1alpha1.ConditionPodScheduled,
+			kraftnetescomv1alpha1.ConditionStorageBound,
+			kraftnetescomv1alpha1.ConditionReady,
+		} {
+			Expect(meta.IsStatusConditionTrue(status.Conditions, conditionType)).To(BeTrue(), conditionType)
+		}
+	})
+})
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// retainPolicyAnnotation and snapshotClassAnnotation record the retention settings on the claim, so deleting
+	// a GameServer doesn't depend on its GameDefinition still being there.
+	retainPolicyAnnotation  = "kraftnetes.com/retain-policy"
+	snapshotClassAnnotation = "kraftnetes.com/snapshot-class"
+	// retainedLabel marks claims left behind by a deleted GameServer, find them with
+	// kubectl get pvc -l kraftnetes.com/retained=true.
+	retainedLabel = "kraftnetes.com/retained"
+	// retainedFromAnnotation and retainedAtAnnotation record which GameServer left a claim behind, and when.
+	retainedFromAnnotation = "kraftnetes.com/retained-from"
+	retainedAtAnnotation   = "kraftnetes.com/retained-at"
+	// gameServerIdLabel carries the kraftnetes-id on objects that outlive their GameServer.
+	gameServerIdLabel = "kraftnetes-id"
+
+	finalSnapshotTimeout      = 10 * time.Minute
+	finalSnapshotPollInterval = 5 * time.Second
+)
+
+// volumeSnapshotGVK is the CSI VolumeSnapshot. Like HTTPRoutes, snapshots are handled as unstructured objects
+// and only need the CRD when retainPolicy Snapshot is used.
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// retainPolicy returns the RetainPolicy of the GameServer's storage, Delete if unset.
+func retainPolicy(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) v1alpha1.RetainPolicy {
+	if storage := storageConfig(gs, gameDef); storage != nil && storage.RetainPolicy != "" {
+		return storage.RetainPolicy
+	}
+	return v1alpha1.RetainPolicyDelete
+}
+
+// retentionAnnotations returns the annotations recording the retention settings on the claim.
+func retentionAnnotations(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) map[string]string {
+	annotations := map[string]string{retainPolicyAnnotation: string(retainPolicy(gs, gameDef))}
+	if storage := storageConfig(gs, gameDef); storage != nil && storage.SnapshotClassName != "" {
+		annotations[snapshotClassAnnotation] = storage.SnapshotClassName
+	}
+	return annotations
+}
+
+// updatePvcMetadata adopts a claim retained by an earlier GameServer with the same kraftnetes-id and keeps the
+// retention annotations of the claim in line with the GameDefinition.
+func (r *GameServerReconciler) updatePvcMetadata(ctx context.Context, gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition, pvc *corev1.PersistentVolumeClaim) error {
+	logger := log.FromContext(ctx)
+	original := pvc.DeepCopy()
+
+	adopted := false
+	if !metav1.IsControlledBy(pvc, gs) && pvc.Labels[retainedLabel] == "true" {
+		if err := controllerutil.SetControllerReference(gs, pvc, r.Scheme); err != nil {
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "OwnerRefError", err.Error())
+			return err
+		}
+		delete(pvc.Labels, retainedLabel)
+		delete(pvc.Annotations, retainedFromAnnotation)
+		delete(pvc.Annotations, retainedAtAnnotation)
+		pvc.Labels["gameserver"] = gs.Name
+		adopted = true
+	}
+
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	delete(pvc.Annotations, snapshotClassAnnotation)
+	for key, value := range retentionAnnotations(gs, gameDef) {
+		pvc.Annotations[key] = value
+	}
+
+	if !adopted && maps.Equal(original.Annotations, pvc.Annotations) {
+		return nil
+	}
+	if err := r.Patch(ctx, pvc, client.MergeFrom(original)); err != nil {
+		logger.Error(err, "Failed to update Pvc")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcUpdateFailed", err.Error())
+		return err
+	}
+	if adopted {
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PvcAdopted", "Adopted retained pvc %s", pvc.Name)
+		logger.Info("Adopted retained Pvc", "name", pvc.Name)
+	}
+	return nil
+}
+
+// retainStorage applies the claim's RetainPolicy while the GameServer is deleted, after its Pod is gone. It
+// reports whether the finalizer can be removed, and if not, when to check again. Delete leaves the claim to the
+// garbage collector, Retain releases it and Snapshot waits for a final VolumeSnapshot first.
+func (r *GameServerReconciler) retainStorage(ctx context.Context, gs *v1alpha1.GameServer) (bool, time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("gs-%s-pvc", ResolveGameServerId(gs)), Namespace: gs.Namespace}, pvc)
+	if apierrors.IsNotFound(err) {
+		return true, 0, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to get pvc")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcLookupFailed", err.Error())
+		return false, 0, err
+	}
+	if !metav1.IsControlledBy(pvc, gs) {
+		return true, 0, nil
+	}
+
+	switch v1alpha1.RetainPolicy(pvc.Annotations[retainPolicyAnnotation]) {
+	case v1alpha1.RetainPolicyRetain:
+		return true, 0, r.releasePvc(ctx, gs, pvc)
+	case v1alpha1.RetainPolicySnapshot:
+		ready, failure, err := r.finalSnapshot(ctx, gs, pvc)
+		if err != nil {
+			return false, 0, err
+		}
+		if failure != "" {
+			// Never lose the data because the snapshot didn't work out.
+			r.Recorder.Eventf(gs, corev1.EventTypeWarning, "FinalSnapshotFailed", "%s, retaining pvc %s instead", failure, pvc.Name)
+			return true, 0, r.releasePvc(ctx, gs, pvc)
+		}
+		if !ready {
+			return false, finalSnapshotPollInterval, nil
+		}
+		return true, 0, nil
+	}
+	return true, 0, nil
+}
+
+// releasePvc removes the GameServer's owner reference from the claim, so it survives the GameServer, and labels
+// it for adoption by the next GameServer with the same kraftnetes-id.
+func (r *GameServerReconciler) releasePvc(ctx context.Context, gs *v1alpha1.GameServer, pvc *corev1.PersistentVolumeClaim) error {
+	logger := log.FromContext(ctx)
+	id := ResolveGameServerId(gs)
+
+	patch := client.MergeFrom(pvc.DeepCopy())
+	var owners []metav1.OwnerReference
+	for _, owner := range pvc.OwnerReferences {
+		if owner.UID != gs.UID {
+			owners = append(owners, owner)
+		}
+	}
+	pvc.OwnerReferences = owners
+	if pvc.Labels == nil {
+		pvc.Labels = map[string]string{}
+	}
+	pvc.Labels[retainedLabel] = "true"
+	pvc.Labels[gameServerIdLabel] = id
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[retainedFromAnnotation] = gs.Name
+	pvc.Annotations[retainedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := r.Patch(ctx, pvc, patch); err != nil {
+		logger.Error(err, "Failed to release Pvc")
+		r.Recorder.Event(gs, corev1.EventTypeWarning, "PvcReleaseFailed", err.Error())
+		return err
+	}
+
+	r.Recorder.Eventf(gs, corev1.EventTypeNormal, "PvcRetained",
+		"Retained pvc %s, a new GameServer with kraftnetes-id %s adopts it", pvc.Name, id)
+	logger.Info("Retained Pvc", "name", pvc.Name, "kraftnetes-id", id)
+	return nil
+}
+
+// finalSnapshot creates a VolumeSnapshot of the claim once and reports whether it is ready to use. A non-empty
+// failure means the snapshot can't be taken, e.g. because the CRD isn't installed or it timed out.
+func (r *GameServerReconciler) finalSnapshot(ctx context.Context, gs *v1alpha1.GameServer, pvc *corev1.PersistentVolumeClaim) (bool, string, error) {
+	logger := log.FromContext(ctx)
+
+	snapshot := buildFinalSnapshot(gs, pvc)
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(volumeSnapshotGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: snapshot.GetName(), Namespace: snapshot.GetNamespace()}, existing)
+	if meta.IsNoMatchError(err) {
+		return false, "the VolumeSnapshot CRD is not installed", nil
+	}
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, snapshot); err != nil {
+			logger.Error(err, "Failed to create VolumeSnapshot")
+			r.Recorder.Event(gs, corev1.EventTypeWarning, "FinalSnapshotCreateFailed", err.Error())
+			return false, "", err
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "FinalSnapshotCreated", "Created VolumeSnapshot %s of pvc %s", snapshot.GetName(), pvc.Name)
+		logger.Info("Created final VolumeSnapshot", "name", snapshot.GetName())
+		return false, "", nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to get VolumeSnapshot")
+		return false, "", err
+	}
+
+	ready, failure := volumeSnapshotReady(existing, time.Now())
+	if ready {
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "FinalSnapshotReady", "VolumeSnapshot %s of pvc %s is ready", existing.GetName(), pvc.Name)
+	}
+	return ready, failure, nil
+}
+
+// volumeSnapshotReady reports whether the snapshot is ready to use, or why it never will be.
+func volumeSnapshotReady(snapshot *unstructured.Unstructured, now time.Time) (bool, string) {
+	if ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse"); ready {
+		return true, ""
+	}
+	if message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); message != "" {
+		return false, fmt.Sprintf("VolumeSnapshot %s failed: %s", snapshot.GetName(), message)
+	}
+	if now.Sub(snapshot.GetCreationTimestamp().Time) > finalSnapshotTimeout {
+		return false, fmt.Sprintf("VolumeSnapshot %s wasn't ready within %s", snapshot.GetName(), finalSnapshotTimeout)
+	}
+	return false, ""
+}
+
+// buildFinalSnapshot returns the VolumeSnapshot taken of the claim before it is deleted. It isn't owned by the
+// GameServer and carries its kraftnetes-id, so it can be found and restored from later.
+func buildFinalSnapshot(gs *v1alpha1.GameServer, pvc *corev1.PersistentVolumeClaim) *unstructured.Unstructured {
+	id := ResolveGameServerId(gs)
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	// The deletion timestamp keeps the name stable while waiting, and apart from earlier GameServers with this id.
+	snapshot.SetName(fmt.Sprintf("gs-%s-final-%d", id, gs.DeletionTimestamp.Unix()))
+	snapshot.SetNamespace(gs.Namespace)
+	snapshot.SetLabels(map[string]string{
+		"app":             "gameserver",
+		"gameserver":      gs.Name,
+		gameServerIdLabel: id,
+	})
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": pvc.Name},
+	}
+	if class := pvc.Annotations[snapshotClassAnnotation]; class != "" {
+		spec["volumeSnapshotClassName"] = class
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
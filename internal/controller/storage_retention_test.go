@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Storage retention", func() {
+	var (
+		ctx        = context.Background()
+		reconciler *GameServerReconciler
+		gs         *kraftnetescomv1alpha1.GameServer
+		pvc        *corev1.PersistentVolumeClaim
+	)
+
+	BeforeEach(func() {
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "survival",
+				Namespace: "default",
+				UID:       "uid-1",
+				Labels:    map[string]string{"kraftnetes-id": "abc123"},
+			},
+		}
+		pvc = &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:        "gs-abc123-pvc",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "gameserver", "gameserver": "survival"},
+			Annotations: map[string]string{retainPolicyAnnotation: string(kraftnetescomv1alpha1.RetainPolicyRetain)},
+		}}
+		Expect(controllerutil.SetControllerReference(gs, pvc, newTestScheme())).To(Succeed())
+
+		reconciler, _ = newFakeGameServerReconciler(pvc)
+	})
+
+	It("releases a retained claim and lets the next GameServer with the same id adopt it", func() {
+		done, _, err := reconciler.retainStorage(ctx, gs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(BeTrue())
+
+		released := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "gs-abc123-pvc", Namespace: "default"}, released)).To(Succeed())
+		Expect(released.OwnerReferences).To(BeEmpty())
+		Expect(released.Labels).To(HaveKeyWithValue(retainedLabel, "true"))
+		Expect(released.Labels).To(HaveKeyWithValue("kraftnetes-id", "abc123"))
+		Expect(released.Annotations).To(HaveKeyWithValue(retainedFromAnnotation, "survival"))
+
+		successor := &kraftnetescomv1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{
+			Name:      "survival-2",
+			Namespace: "default",
+			UID:       "uid-2",
+			Labels:    map[string]string{"kraftnetes-id": "abc123"},
+		}}
+		gameDef := &kraftnetescomv1alpha1.GameDefinition{Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+			Storage: &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromBool(true), RetainPolicy: kraftnetescomv1alpha1.RetainPolicySnapshot},
+		}}
+		Expect(reconciler.updatePvcMetadata(ctx, successor, gameDef, released)).To(Succeed())
+
+		adopted := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "gs-abc123-pvc", Namespace: "default"}, adopted)).To(Succeed())
+		Expect(metav1.IsControlledBy(adopted, successor)).To(BeTrue())
+		Expect(adopted.Labels).NotTo(HaveKey(retainedLabel))
+		Expect(adopted.Labels).To(HaveKeyWithValue("gameserver", "survival-2"))
+		Expect(adopted.Annotations).To(HaveKeyWithValue(retainPolicyAnnotation, "Snapshot"))
+		Expect(adopted.Annotations).NotTo(HaveKey(retainedFromAnnotation))
+	})
+
+	It("releases the claim only once the Pod is gone", func() {
+		deletedAt := metav1.Now()
+		gs.DeletionTimestamp = &deletedAt
+		gs.Finalizers = []string{gameServerFinalizer}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:       "gs-abc123-pod",
+			Namespace:  "default",
+			Finalizers: []string{"kraftnetes.com/test"},
+		}}
+		reconciler, _ = newFakeGameServerReconciler(gs, pvc, pod)
+		claimKey := types.NamespacedName{Name: "gs-abc123-pvc", Namespace: "default"}
+
+		// The Pod is terminating, the game may still write to the claim.
+		for range 2 {
+			result, err := reconciler.reconcileDelete(ctx, gs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(stopPollInterval))
+			Expect(reconciler.Get(ctx, claimKey, pvc)).To(Succeed())
+			Expect(metav1.IsControlledBy(pvc, gs)).To(BeTrue())
+		}
+
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: "default"}, pod)).To(Succeed())
+		pod.Finalizers = nil
+		Expect(reconciler.Update(ctx, pod)).To(Succeed())
+		result, err := reconciler.reconcileDelete(ctx, gs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(reconciler.Get(ctx, claimKey, pvc)).To(Succeed())
+		Expect(pvc.OwnerReferences).To(BeEmpty())
+	})
+
+	It("leaves claims with the Delete policy to the garbage collector", func() {
+		pvc.Annotations[retainPolicyAnnotation] = string(kraftnetescomv1alpha1.RetainPolicyDelete)
+		Expect(reconciler.Update(ctx, pvc)).To(Succeed())
+
+		done, _, err := reconciler.retainStorage(ctx, gs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(BeTrue())
+
+		kept := &corev1.PersistentVolumeClaim{}
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "gs-abc123-pvc", Namespace: "default"}, kept)).To(Succeed())
+		Expect(metav1.IsControlledBy(kept, gs)).To(BeTrue())
+	})
+
+	It("names the final snapshot after the deletion and waits until it is ready", func() {
+		deletedAt := metav1.NewTime(time.Unix(1700000000, 0))
+		gs.DeletionTimestamp = &deletedAt
+		pvc.Annotations[snapshotClassAnnotation] = "csi-snapclass"
+		snapshot := buildFinalSnapshot(gs, pvc)
+		Expect(snapshot.GetName()).To(Equal("gs-abc123-final-1700000000"))
+		Expect(snapshot.GetLabels()).To(HaveKeyWithValue("kraftnetes-id", "abc123"))
+		Expect(snapshot.GetOwnerReferences()).To(BeEmpty())
+		class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+		Expect(class).To(Equal("csi-snapclass"))
+
+		now := time.Now()
+		snapshot.SetCreationTimestamp(metav1.NewTime(now))
+		ready, failure := volumeSnapshotReady(snapshot, now)
+		Expect(ready).To(BeFalse())
+		Expect(failure).To(BeEmpty())
+
+		Expect(unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse")).To(Succeed())
+		ready, failure = volumeSnapshotReady(snapshot, now)
+		Expect(ready).To(BeTrue())
+		Expect(failure).To(BeEmpty())
+
+		unstructured.RemoveNestedField(snapshot.Object, "status")
+		_, failure = volumeSnapshotReady(snapshot, now.Add(finalSnapshotTimeout+time.Minute))
+		Expect(failure).To(ContainSubstring("wasn't ready"))
+	})
+})
@@ -26,9 +26,13 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -94,3 +98,56 @@ var _ = AfterSuite(func() {
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
 })
+
+// newTestScheme returns a scheme with the built-in types and the operator's own resources.
+func newTestScheme() *apiruntime.Scheme {
+	testScheme := apiruntime.NewScheme()
+	Expect(scheme.AddToScheme(testScheme)).To(Succeed())
+	Expect(kraftnetescomv1alpha1.AddToScheme(testScheme)).To(Succeed())
+	return testScheme
+}
+
+// newFakeClient returns a fake client holding objects, with the status subresource of the operator's resources
+// and the GameServer indexes of the manager, and the scheme it uses.
+func newFakeClient(objects ...client.Object) (client.Client, *apiruntime.Scheme) {
+	testScheme := newTestScheme()
+	return fake.NewClientBuilder().WithScheme(testScheme).
+		WithObjects(objects...).
+		WithIndex(&kraftnetescomv1alpha1.GameServer{}, gameServerGameIndex, gameServerGame).
+		WithIndex(&kraftnetescomv1alpha1.GameServer{}, gameServerHostPortsIndex, gameServerHostPorts).
+		WithStatusSubresource(&kraftnetescomv1alpha1.GameServer{}, &kraftnetescomv1alpha1.GameServerBackup{},
+			&kraftnetescomv1alpha1.GameDefinition{}).
+		Build(), testScheme
+}
+
+// newFakeGameServerReconciler returns a GameServerReconciler on a fake client holding objects, with the
+// default images and a FakeRecorder collecting its Events.
+func newFakeGameServerReconciler(objects ...client.Object) (*GameServerReconciler, *record.FakeRecorder) {
+	c, testScheme := newFakeClient(objects...)
+	recorder := record.NewFakeRecorder(20)
+	return &GameServerReconciler{
+		Client:        c,
+		Scheme:        testScheme,
+		Recorder:      recorder,
+		BackupImage:   DefaultBackupImage,
+		BackupS3Image: DefaultBackupS3Image,
+		SeedImage:     DefaultSeedImage,
+	}, recorder
+}
+
+// fakePodExecutor records the stop and save requests sent to containers and fails them with err.
+type fakePodExecutor struct {
+	stdin    []string
+	commands [][]string
+	err      error
+}
+
+func (e *fakePodExecutor) Exec(_ context.Context, _ *corev1.Pod, _ string, command []string) error {
+	e.commands = append(e.commands, command)
+	return e.err
+}
+
+func (e *fakePodExecutor) SendStdin(_ context.Context, _ *corev1.Pod, _ string, line string) error {
+	e.stdin = append(e.stdin, line)
+	return e.err
+}
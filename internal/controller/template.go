@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// resolveGameDefinitionSpec substitutes the ${name} placeholders in the GameDefinition spec with the
+// GameServer's inputs, falling back to the defaults declared in the GameDefinition's inputs, and with the
+// built-in variables from builtinValues.
+//
+// The spec is decoded and walked value by value, so input values are never spliced into JSON text. A string
+// that consists of a single placeholder takes the input's typed value (number or boolean) where the field
+// accepts one, e.g. an IntOrString port; everywhere else the value is formatted into the string. Numeric
+// strings in IntOrString fields become numbers, so a quoted port or one filled from a string input is used as
+// a port number. $${name} is an escape for a literal ${name}. Secret inputs are only allowed as an entire env
+// value, which is rendered as a secretKeyRef. Every placeholder without a value is reported with its JSON path.
+func resolveGameDefinitionSpec(gs *v1alpha1.GameServer, gameDef *v1alpha1.GameDefinition) (v1alpha1.GameDefinitionSpec, error) {
+	spec := gameDef.Spec
+	values, err := inputValues(gs, gameDef.Inputs)
+	if err != nil {
+		return spec, err
+	}
+	for name, value := range builtinValues(gs, gameDef) {
+		values[name] = value
+	}
+
+	tree, err := decodeTree(spec)
+	if err != nil {
+		return spec, fmt.Errorf("failed to decode GameDefinition spec: %w", err)
+	}
+
+	t := &templateRenderer{values: values}
+	tree = t.render(tree, reflect.TypeOf(spec), field.NewPath("spec"))
+	if len(t.unresolved) > 0 {
+		return spec, fmt.Errorf("unresolved placeholders in GameDefinition spec: %s", strings.Join(t.unresolved, ", "))
+	}
+	if len(t.misplaced) > 0 {
+		return spec, fmt.Errorf("secret inputs can only be used as an entire env value: %s", strings.Join(t.misplaced, ", "))
+	}
+
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return spec, fmt.Errorf("failed to encode resolved GameDefinition spec: %w", err)
+	}
+	var resolvedSpec v1alpha1.GameDefinitionSpec
+	if err := json.Unmarshal(raw, &resolvedSpec); err != nil {
+		return spec, fmt.Errorf("failed to decode resolved GameDefinition spec: %w", err)
+	}
+	return resolvedSpec, nil
+}
+
+// decodeTree converts v into generic JSON values, keeping numbers as json.Number.
+func decodeTree(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// templateRenderer walks a decoded spec next to the Go type it was encoded from, which tells it whether a
+// field only accepts strings.
+type templateRenderer struct {
+	values     map[string]interface{}
+	unresolved []string
+	misplaced  []string
+}
+
+var (
+	envVarType      = reflect.TypeOf(corev1.EnvVar{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+)
+
+func (t *templateRenderer) render(node interface{}, typ reflect.Type, path *field.Path) interface{} {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if typ == envVarType {
+			if ref, ok := t.secretEnvValue(n); ok {
+				delete(n, "value")
+				n["valueFrom"] = map[string]interface{}{
+					"secretKeyRef": map[string]interface{}{"name": ref.Name, "key": ref.Key},
+				}
+				return n
+			}
+		}
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			n[key] = t.render(n[key], childType(typ, key), childPath(typ, path, key))
+		}
+		return n
+	case []interface{}:
+		var elem reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elem = typ.Elem()
+		}
+		for i := range n {
+			n[i] = t.render(n[i], elem, path.Index(i))
+		}
+		return n
+	case string:
+		rendered := t.renderString(n, typ == nil || typ.Kind() == reflect.String, path)
+		// A numeric IntOrString is used as a number, e.g. a quoted port or one filled from a string input.
+		if s, ok := rendered.(string); ok && typ == intOrStringType {
+			if _, err := strconv.ParseInt(s, 10, 32); err == nil {
+				return json.Number(s)
+			}
+		}
+		return rendered
+	default:
+		return node
+	}
+}
+
+// secretEnvValue returns the Secret key an env entry refers to if its value is exactly one secret placeholder.
+func (t *templateRenderer) secretEnvValue(env map[string]interface{}) (corev1.SecretKeySelector, bool) {
+	value, ok := env["value"].(string)
+	if !ok {
+		return corev1.SecretKeySelector{}, false
+	}
+	segments := parseTemplate(value)
+	if len(segments) != 1 || !segments[0].placeholder {
+		return corev1.SecretKeySelector{}, false
+	}
+	ref, ok := t.values[segments[0].text].(corev1.SecretKeySelector)
+	return ref, ok
+}
+
+// renderString substitutes the placeholders in s. Unless stringOnly is set, a string that is exactly one
+// placeholder is replaced by the typed value.
+func (t *templateRenderer) renderString(s string, stringOnly bool, path *field.Path) interface{} {
+	segments := parseTemplate(s)
+	if !stringOnly && len(segments) == 1 && segments[0].placeholder {
+		if value, ok := t.values[segments[0].text]; ok {
+			if _, secret := value.(corev1.SecretKeySelector); !secret {
+				return value
+			}
+		}
+	}
+
+	var out strings.Builder
+	for _, segment := range segments {
+		if !segment.placeholder {
+			out.WriteString(segment.text)
+			continue
+		}
+		value, ok := t.values[segment.text]
+		if !ok {
+			t.unresolved = append(t.unresolved, fmt.Sprintf("%s: ${%s}", path, segment.text))
+			continue
+		}
+		if _, secret := value.(corev1.SecretKeySelector); secret {
+			t.misplaced = append(t.misplaced, fmt.Sprintf("%s: ${%s}", path, segment.text))
+			continue
+		}
+		out.WriteString(formatTemplateValue(value))
+	}
+	return out.String()
+}
+
+// formatTemplateValue formats a typed input value for use inside a string.
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// templateSegment is a literal piece of a template string or, if placeholder is set, the name of a placeholder.
+type templateSegment struct {
+	text        string
+	placeholder bool
+}
+
+// parseTemplate splits s into literal text and ${name} placeholders. $${ is an escaped, literal ${ and
+// a ${ without a closing brace is kept as text.
+func parseTemplate(s string) []templateSegment {
+	var segments []templateSegment
+	var literal strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			literal.WriteString("${")
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				literal.WriteString(s[i:])
+				i = len(s)
+				continue
+			}
+			if literal.Len() > 0 {
+				segments = append(segments, templateSegment{text: literal.String()})
+				literal.Reset()
+			}
+			segments = append(segments, templateSegment{text: s[i+2 : i+2+end], placeholder: true})
+			i += end + 3
+		default:
+			literal.WriteByte(s[i])
+			i++
+		}
+	}
+	if literal.Len() > 0 {
+		segments = append(segments, templateSegment{text: literal.String()})
+	}
+	return segments
+}
+
+// templatePlaceholders returns the names of the placeholders in s, ignoring escaped ones.
+func templatePlaceholders(s string) []string {
+	var names []string
+	for _, segment := range parseTemplate(s) {
+		if segment.placeholder {
+			names = append(names, segment.text)
+		}
+	}
+	return names
+}
+
+// childType returns the Go type of the field or map value encoded under key, or nil if it isn't known.
+func childType(typ reflect.Type, key string) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Map:
+		return typ.Elem()
+	case reflect.Struct:
+		if f, ok := jsonField(typ, key); ok {
+			return f.Type
+		}
+	}
+	return nil
+}
+
+// childPath extends path with key, as a map key for maps and as a field name for structs.
+func childPath(typ reflect.Type, path *field.Path, key string) *field.Path {
+	if typ != nil && typ.Kind() == reflect.Map {
+		return path.Key(key)
+	}
+	return path.Child(key)
+}
+
+// jsonField finds the struct field that encoding/json maps to key, looking into embedded structs.
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if inner, ok := jsonField(embedded, key); ok {
+					return inner, true
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
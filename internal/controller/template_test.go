@@ -0,0 +1,182 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("Template rendering", func() {
+	var (
+		spec      kraftnetescomv1alpha1.GameDefinitionSpec
+		defInputs map[string]kraftnetescomv1alpha1.GameDefinitionInput
+		gsInputs  map[string]apiextensionsv1.JSON
+		gs        *kraftnetescomv1alpha1.GameServer
+	)
+
+	resolve := func() (kraftnetescomv1alpha1.GameDefinitionSpec, error) {
+		gs.Spec.Inputs = gsInputs
+		return resolveGameDefinitionSpec(gs, &kraftnetescomv1alpha1.GameDefinition{Inputs: defInputs, Spec: spec})
+	}
+
+	BeforeEach(func() {
+		spec = kraftnetescomv1alpha1.GameDefinitionSpec{
+			Game:        "minecraft",
+			Image:       "itzg/minecraft-server:${version}",
+			FileBrowser: kraftnetescomv1alpha1.FromString("${files}"),
+			Ports:       []kraftnetescomv1alpha1.GamePort{{Name: "minecraft", ContainerPort: intstr.FromString("${port}")}},
+			Env: []corev1.EnvVar{
+				{Name: "VERSION", Value: "${version}"},
+				{Name: "MOTD", Value: "${motd}"},
+				{Name: "PORT", Value: "${port}"},
+			},
+		}
+		defInputs = map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+			"version": {Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "1.21.5"}},
+			"motd":    {Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "Welcome"}},
+			"port":    {Type: "number", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "25565"}},
+			"files":   {Type: "boolean", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "true"}},
+		}
+		gsInputs = map[string]apiextensionsv1.JSON{}
+		gs = &kraftnetescomv1alpha1.GameServer{}
+	})
+
+	It("keeps values that only look numeric as strings", func() {
+		gsInputs["version"] = apiextensionsv1.JSON{Raw: []byte(`"1.20"`)}
+		resolved, err := resolve()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved.Image).To(Equal("itzg/minecraft-server:1.20"))
+		Expect(resolved.Env[0].Value).To(Equal("1.20"))
+	})
+
+	It("uses typed values where the field accepts them", func() {
+		resolved, err := resolve()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved.Ports[0].ContainerPort).To(Equal(intstr.FromInt(25565)))
+		Expect(resolved.FileBrowser).To(Equal(kraftnetescomv1alpha1.FromBool(true)))
+		Expect(resolved.Env[2].Value).To(Equal("25565"))
+	})
+
+	It("turns quoted numeric ports into numbers", func() {
+		defInputs["queryPort"] = kraftnetescomv1alpha1.GameDefinitionInput{Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "25566"}}
+		spec.Ports = append(spec.Ports,
+			kraftnetescomv1alpha1.GamePort{Name: "rcon", ContainerPort: intstr.FromString("25575")},
+			kraftnetescomv1alpha1.GamePort{Name: "query", ContainerPort: intstr.FromString("${queryPort}")},
+		)
+		resolved, err := resolve()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved.Ports[1].ContainerPort).To(Equal(intstr.FromInt(25575)))
+		Expect(resolved.Ports[2].ContainerPort).To(Equal(intstr.FromInt(25566)))
+	})
+
+	It("doesn't let quotes or backslashes escape the field", func() {
+		motd := `a "quoted", \ value","injected":"x`
+		gsInputs["motd"] = apiextensionsv1.JSON{Raw: []byte(`"a \"quoted\", \\ value\",\"injected\":\"x"`)}
+		resolved, err := resolve()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved.Env[1].Value).To(Equal(motd))
+		Expect(resolved.Env).To(HaveLen(3))
+	})
+
+	It("turns $${...} into a literal placeholder", func() {
+		spec.Env[1].Value = "$${motd} is ${motd}"
+		resolved, err := resolve()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved.Env[1].Value).To(Equal("${motd} is Welcome"))
+	})
+
+	It("rejects values that don't match the input type", func() {
+		gsInputs["port"] = apiextensionsv1.JSON{Raw: []byte(`"25565"`)}
+		_, err := resolve()
+		Expect(err).To(MatchError(ContainSubstring(`input "port"`)))
+	})
+
+	It("reports the path of every unresolved placeholder", func() {
+		spec.Image = "itzg/minecraft-server:${tag}"
+		spec.Env[1].Value = "${greeting}"
+		_, err := resolve()
+		Expect(err).To(MatchError(ContainSubstring("spec.image: ${tag}")))
+		Expect(err).To(MatchError(ContainSubstring("spec.env[1].value: ${greeting}")))
+	})
+
+	Context("with secret inputs", func() {
+		BeforeEach(func() {
+			defInputs["rconPassword"] = kraftnetescomv1alpha1.GameDefinitionInput{Type: "secret"}
+			spec.Env = append(spec.Env, corev1.EnvVar{Name: "RCON_PASSWORD", Value: "${rconPassword}"})
+		})
+
+		It("renders a generated secret as a secretKeyRef to the input Secret", func() {
+			resolved, err := resolve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Env[3].Value).To(BeEmpty())
+			Expect(resolved.Env[3].ValueFrom.SecretKeyRef.Name).To(Equal("gs--inputs"))
+			Expect(resolved.Env[3].ValueFrom.SecretKeyRef.Key).To(Equal("rconPassword"))
+		})
+
+		It("renders valueFrom as a secretKeyRef to the given Secret", func() {
+			gsInputs["rconPassword"] = apiextensionsv1.JSON{Raw: []byte(`{"valueFrom":{"secretKeyRef":{"name":"rcon","key":"password"}}}`)}
+			resolved, err := resolve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Env[3].ValueFrom.SecretKeyRef.Name).To(Equal("rcon"))
+			Expect(resolved.Env[3].ValueFrom.SecretKeyRef.Key).To(Equal("password"))
+		})
+
+		It("rejects clear text values", func() {
+			gsInputs["rconPassword"] = apiextensionsv1.JSON{Raw: []byte(`"hunter2"`)}
+			_, err := resolve()
+			Expect(err).To(MatchError(ContainSubstring("valueFrom")))
+		})
+
+		It("refuses secrets outside of an entire env value", func() {
+			spec.Env[3].Value = "--password=${rconPassword}"
+			_, err := resolve()
+			Expect(err).To(MatchError(ContainSubstring("spec.env[3].value: ${rconPassword}")))
+		})
+	})
+
+	Context("with built-in variables", func() {
+		BeforeEach(func() {
+			gs.ObjectMeta = metav1.ObjectMeta{Name: "survival", Namespace: "games", Labels: map[string]string{"kraftnetes-id": "abc123"}}
+			gs.Spec.Resources = corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+					corev1.ResourceCPU:    resource.MustParse("1500m"),
+				},
+			}
+			gs.Status.HostPorts = []kraftnetescomv1alpha1.HostPortStatus{{Name: "minecraft", HostPort: 30123}}
+			spec.Ports[0].Type = "HostPort"
+		})
+
+		It("resolves the GameServer identity", func() {
+			spec.Env[1].Value = "${gs.name} (${gs.id}) in ${gs.namespace}"
+			resolved, err := resolve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Env[1].Value).To(Equal("survival (abc123) in games"))
+		})
+
+		It("resolves the allocated host port and merged resources", func() {
+			spec.Env = append(spec.Env,
+				corev1.EnvVar{Name: "SERVER_PORT", Value: "${ports.minecraft.hostPort}"},
+				corev1.EnvVar{Name: "MEMORY", Value: "${resources.limits.memoryMi}M"},
+				corev1.EnvVar{Name: "CPU", Value: "${resources.limits.cpuMilli}"},
+			)
+			resolved, err := resolve()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Env[3].Value).To(Equal("30123"))
+			Expect(resolved.Env[4].Value).To(Equal("4096M"))
+			Expect(resolved.Env[5].Value).To(Equal("1500"))
+		})
+
+		It("reports resources the GameServer doesn't set", func() {
+			spec.Env[1].Value = "${resources.requests.memoryMi}"
+			_, err := resolve()
+			Expect(err).To(MatchError(ContainSubstring("spec.env[1].value: ${resources.requests.memoryMi}")))
+		})
+	})
+})
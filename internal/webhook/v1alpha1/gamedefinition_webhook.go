@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	"github.com/Kraftnetes/k8s-operator/internal/controller"
+)
+
+// log is for logging in this package.
+var gamedefinitionlog = logf.Log.WithName("gamedefinition-resource")
+
+// SetupGameDefinitionWebhookWithManager registers the webhook for GameDefinition in the manager.
+func SetupGameDefinitionWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&kraftnetescomv1alpha1.GameDefinition{}).
+		WithValidator(&GameDefinitionCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-kraftnetes-com-v1alpha1-gamedefinition,mutating=false,failurePolicy=fail,sideEffects=None,groups=kraftnetes.com,resources=gamedefinitions,verbs=create;update,versions=v1alpha1,name=vgamedefinition-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GameDefinitionCustomValidator rejects GameDefinitions that the controller would report as not Valid.
+type GameDefinitionCustomValidator struct{}
+
+var _ webhook.CustomValidator = &GameDefinitionCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GameDefinition.
+func (v *GameDefinitionCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	gamedefinition, ok := obj.(*kraftnetescomv1alpha1.GameDefinition)
+	if !ok {
+		return nil, fmt.Errorf("expected a GameDefinition object but got %T", obj)
+	}
+	gamedefinitionlog.Info("Validation for GameDefinition upon creation", "name", gamedefinition.GetName())
+
+	return nil, validateGameDefinition(gamedefinition)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GameDefinition.
+func (v *GameDefinitionCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	gamedefinition, ok := newObj.(*kraftnetescomv1alpha1.GameDefinition)
+	if !ok {
+		return nil, fmt.Errorf("expected a GameDefinition object for the newObj but got %T", newObj)
+	}
+	gamedefinitionlog.Info("Validation for GameDefinition upon update", "name", gamedefinition.GetName())
+
+	return nil, validateGameDefinition(gamedefinition)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GameDefinition.
+func (v *GameDefinitionCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateGameDefinition(gameDef *kraftnetescomv1alpha1.GameDefinition) error {
+	if errs := controller.ValidateGameDefinition(gameDef); len(errs) > 0 {
+		return apierrors.NewInvalid(kraftnetescomv1alpha1.GroupVersion.WithKind("GameDefinition").GroupKind(), gameDef.Name, errs)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("GameDefinition Webhook", func() {
+	var (
+		ctx       = context.Background()
+		gameDef   *kraftnetescomv1alpha1.GameDefinition
+		validator = &GameDefinitionCustomValidator{}
+	)
+
+	BeforeEach(func() {
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Inputs: map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+				"version": {Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "1.21.5"}},
+			},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:  "minecraft",
+				Image: "itzg/minecraft-server:${version}",
+			},
+		}
+	})
+
+	It("admits a valid GameDefinition", func() {
+		Expect(validator.ValidateCreate(ctx, gameDef)).Error().NotTo(HaveOccurred())
+	})
+
+	It("rejects an invalid storage defaultSize", func() {
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{DefaultSize: "huge"}
+		_, err := validator.ValidateUpdate(ctx, gameDef, gameDef)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.storage.defaultSize"))
+	})
+})
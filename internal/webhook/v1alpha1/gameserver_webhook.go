@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+	"github.com/Kraftnetes/k8s-operator/internal/controller"
+)
+
+// log is for logging in this package.
+var gameserverlog = logf.Log.WithName("gameserver-resource")
+
+// SetupGameServerWebhookWithManager registers the webhook for GameServer in the manager.
+func SetupGameServerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&kraftnetescomv1alpha1.GameServer{}).
+		WithValidator(&GameServerCustomValidator{Client: mgr.GetClient()}).
+		WithDefaulter(&GameServerCustomDefaulter{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-kraftnetes-com-v1alpha1-gameserver,mutating=true,failurePolicy=fail,sideEffects=None,groups=kraftnetes.com,resources=gameservers,verbs=create;update,versions=v1alpha1,name=mgameserver-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GameServerCustomDefaulter fills the GameServer spec with the effective values from its GameDefinition,
+// so that the stored object shows what will actually run.
+type GameServerCustomDefaulter struct {
+	Client client.Client
+}
+
+var _ webhook.CustomDefaulter = &GameServerCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind GameServer.
+func (d *GameServerCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	gameserver, ok := obj.(*kraftnetescomv1alpha1.GameServer)
+	if !ok {
+		return fmt.Errorf("expected a GameServer object but got %T", obj)
+	}
+	gameserverlog.Info("Defaulting for GameServer", "name", gameserver.GetName())
+
+	if !gameserver.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	gameDef := &kraftnetescomv1alpha1.GameDefinition{}
+	if err := d.Client.Get(ctx, types.NamespacedName{Name: gameserver.Spec.Game}, gameDef); err != nil {
+		// Without its GameDefinition there is nothing to default from, the validator warns about it.
+		return client.IgnoreNotFound(err)
+	}
+
+	controller.DefaultGameServer(gameserver, gameDef)
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-kraftnetes-com-v1alpha1-gameserver,mutating=false,failurePolicy=fail,sideEffects=None,groups=kraftnetes.com,resources=gameservers,verbs=create;update,versions=v1alpha1,name=vgameserver-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GameServerCustomValidator rejects GameServers that their GameDefinition can't be resolved for,
+// e.g. an unknown profile, a missing required input or an invalid volumeSize.
+type GameServerCustomValidator struct {
+	Client client.Client
+}
+
+var _ webhook.CustomValidator = &GameServerCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GameServer.
+func (v *GameServerCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	gameserver, ok := obj.(*kraftnetescomv1alpha1.GameServer)
+	if !ok {
+		return nil, fmt.Errorf("expected a GameServer object but got %T", obj)
+	}
+	gameserverlog.Info("Validation for GameServer upon creation", "name", gameserver.GetName())
+
+	return v.validateGameServer(ctx, gameserver)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GameServer.
+func (v *GameServerCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	gameserver, ok := newObj.(*kraftnetescomv1alpha1.GameServer)
+	if !ok {
+		return nil, fmt.Errorf("expected a GameServer object for the newObj but got %T", newObj)
+	}
+	gameserverlog.Info("Validation for GameServer upon update", "name", gameserver.GetName())
+
+	oldGameserver, ok := oldObj.(*kraftnetescomv1alpha1.GameServer)
+	if !ok {
+		return nil, fmt.Errorf("expected a GameServer object for the oldObj but got %T", oldObj)
+	}
+
+	// Let labels, annotations and finalizers change on GameServers whose definition has become invalid.
+	if !gameserver.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(oldGameserver.Spec, gameserver.Spec) {
+		return nil, nil
+	}
+	errs, updateWarnings := controller.ValidateGameServerUpdate(oldGameserver, gameserver)
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(kraftnetescomv1alpha1.GroupVersion.WithKind("GameServer").GroupKind(), gameserver.Name, errs)
+	}
+
+	warnings, err := v.validateGameServer(ctx, gameserver)
+	return append(warnings, updateWarnings...), err
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GameServer.
+func (v *GameServerCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateGameServer resolves the GameServer against its GameDefinition. A GameServer may be created before
+// its GameDefinition, the controller keeps it Pending until the definition exists, so that only warns.
+func (v *GameServerCustomValidator) validateGameServer(ctx context.Context, gs *kraftnetescomv1alpha1.GameServer) (admission.Warnings, error) {
+	gameDef := &kraftnetescomv1alpha1.GameDefinition{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: gs.Spec.Game}, gameDef); err != nil {
+		if apierrors.IsNotFound(err) {
+			return admission.Warnings{fmt.Sprintf("GameDefinition %s not found, the GameServer stays Pending until it exists", gs.Spec.Game)}, nil
+		}
+		return nil, err
+	}
+
+	if errs := controller.ValidateGameServer(gs, gameDef); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(kraftnetescomv1alpha1.GroupVersion.WithKind("GameServer").GroupKind(), gs.Name, errs)
+	}
+	return nil, nil
+}
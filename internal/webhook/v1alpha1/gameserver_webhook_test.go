@@ -0,0 +1,209 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kraftnetescomv1alpha1 "github.com/Kraftnetes/k8s-operator/api/v1alpha1"
+)
+
+var _ = Describe("GameServer Webhook", func() {
+	var (
+		ctx       = context.Background()
+		gameDef   *kraftnetescomv1alpha1.GameDefinition
+		gs        *kraftnetescomv1alpha1.GameServer
+		validator *GameServerCustomValidator
+	)
+
+	BeforeEach(func() {
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Inputs: map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+				"version": {Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "1.21.5"}},
+				"eula":    {Type: "string", Required: true},
+			},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:  "minecraft",
+				Image: "itzg/minecraft-server:${version}",
+				Env:   []corev1.EnvVar{{Name: "EULA", Value: "${eula}"}},
+				Profiles: &kraftnetescomv1alpha1.GameProfiles{
+					Values: []kraftnetescomv1alpha1.GameProfile{{Name: "paper"}},
+				},
+			},
+		}
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default"},
+			Spec: kraftnetescomv1alpha1.GameServerSpec{
+				Game:   "minecraft",
+				Inputs: map[string]apiextensionsv1.JSON{"eula": {Raw: []byte(`"TRUE"`)}},
+			},
+		}
+		validator = &GameServerCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(gameDef).Build(),
+		}
+	})
+
+	It("admits a GameServer that resolves", func() {
+		Expect(validator.ValidateCreate(ctx, gs)).Error().NotTo(HaveOccurred())
+	})
+
+	It("rejects an unknown profile", func() {
+		gs.Spec.Profile = "forge"
+		_, err := validator.ValidateCreate(ctx, gs)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.profile"))
+	})
+
+	It("rejects a missing required input", func() {
+		gs.Spec.Inputs = nil
+		_, err := validator.ValidateCreate(ctx, gs)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.inputs[eula]"))
+	})
+
+	It("rejects an invalid volumeSize", func() {
+		oldGs := gs.DeepCopy()
+		gs.Spec.VolumeSize = "ten gigs"
+		_, err := validator.ValidateUpdate(ctx, oldGs, gs)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.volumeSize"))
+	})
+
+	It("rejects shrinking the volumeSize", func() {
+		gameDef.Spec.Storage = &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromBool(true)}
+		Expect(validator.Client.Update(ctx, gameDef)).To(Succeed())
+		oldGs := gs.DeepCopy()
+		oldGs.Spec.VolumeSize = "20Gi"
+		gs.Spec.VolumeSize = "10Gi"
+		_, err := validator.ValidateUpdate(ctx, oldGs, gs)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("volumes can only grow"))
+
+		gs.Spec.VolumeSize = "30Gi"
+		Expect(validator.ValidateUpdate(ctx, oldGs, gs)).Error().NotTo(HaveOccurred())
+	})
+
+	It("only validates updates that change the spec", func() {
+		// The GameDefinition now requires an input the GameServer doesn't set.
+		gameDef.Inputs["motd"] = kraftnetescomv1alpha1.GameDefinitionInput{Type: "string", Required: true}
+		Expect(validator.Client.Update(ctx, gameDef)).To(Succeed())
+		oldGs := gs.DeepCopy()
+		gs.Finalizers = []string{"kraftnetes.com/finalizer"}
+		gs.Annotations = map[string]string{"example.com/note": "kept"}
+		Expect(validator.ValidateUpdate(ctx, oldGs, gs)).Error().NotTo(HaveOccurred())
+
+		gs.Spec.Profile = "paper"
+		_, err := validator.ValidateUpdate(ctx, oldGs, gs)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+	})
+
+	It("only warns when the GameDefinition doesn't exist yet", func() {
+		gs.Spec.Game = "terraria"
+		warnings, err := validator.ValidateCreate(ctx, gs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("GameServer Defaulting Webhook", func() {
+	var (
+		ctx       = context.Background()
+		gameDef   *kraftnetescomv1alpha1.GameDefinition
+		gs        *kraftnetescomv1alpha1.GameServer
+		defaulter *GameServerCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		files, disabled := kraftnetescomv1alpha1.FromString("${files}"), kraftnetescomv1alpha1.FromBool(false)
+		gameDef = &kraftnetescomv1alpha1.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Inputs: map[string]kraftnetescomv1alpha1.GameDefinitionInput{
+				"version":    {Type: "string", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "1.21.5"}},
+				"maxPlayers": {Type: "number", Default: kraftnetescomv1alpha1.AnyVal{StrVal: "20"}},
+				"files":      {Type: "boolean", Default: kraftnetescomv1alpha1.AnyVal{Type: kraftnetescomv1alpha1.AnyValBool, BoolVal: true}},
+			},
+			Spec: kraftnetescomv1alpha1.GameDefinitionSpec{
+				Game:        "minecraft",
+				Image:       "itzg/minecraft-server:${version}",
+				FileBrowser: kraftnetescomv1alpha1.FromString("${files}"),
+				Storage:     &kraftnetescomv1alpha1.StorageConfig{Enabled: kraftnetescomv1alpha1.FromBool(true)},
+				Profiles: &kraftnetescomv1alpha1.GameProfiles{
+					Default: "vanilla",
+					Values: []kraftnetescomv1alpha1.GameProfile{
+						{Name: "vanilla", FileBrowser: &files},
+						{Name: "paper", FileBrowser: &disabled},
+						{Name: "fabric"},
+					},
+				},
+			},
+		}
+		gs = &kraftnetescomv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival", Namespace: "default"},
+			Spec:       kraftnetescomv1alpha1.GameServerSpec{Game: "minecraft"},
+		}
+		defaulter = &GameServerCustomDefaulter{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(gameDef).Build(),
+		}
+	})
+
+	It("fills the effective profile, inputs, filebrowser and volumeSize", func() {
+		Expect(defaulter.Default(ctx, gs)).To(Succeed())
+
+		Expect(gs.Spec.Profile).To(Equal("vanilla"))
+		Expect(gs.Spec.VolumeSize).To(Equal("10Gi"))
+		Expect(gs.Spec.Filebrowser).To(HaveValue(BeTrue()))
+		Expect(gs.Spec.Inputs).To(HaveKeyWithValue("version", apiextensionsv1.JSON{Raw: []byte(`"1.21.5"`)}))
+		Expect(gs.Spec.Inputs).To(HaveKeyWithValue("maxPlayers", apiextensionsv1.JSON{Raw: []byte(`20`)}))
+		Expect(gs.Spec.Inputs).To(HaveKeyWithValue("files", apiextensionsv1.JSON{Raw: []byte(`true`)}))
+	})
+
+	It("keeps values that are already set", func() {
+		gameDef.Spec.Storage.DefaultSize = "20Gi"
+		gs.Spec.Profile = "paper"
+		gs.Spec.Inputs = map[string]apiextensionsv1.JSON{"version": {Raw: []byte(`"1.20.1"`)}}
+		Expect(defaulter.Client.Update(ctx, gameDef)).To(Succeed())
+
+		Expect(defaulter.Default(ctx, gs)).To(Succeed())
+
+		Expect(gs.Spec.Profile).To(Equal("paper"))
+		Expect(gs.Spec.VolumeSize).To(Equal("20Gi"))
+		Expect(gs.Spec.Filebrowser).To(HaveValue(BeFalse()))
+		Expect(gs.Spec.Inputs).To(HaveKeyWithValue("version", apiextensionsv1.JSON{Raw: []byte(`"1.20.1"`)}))
+	})
+
+	It("keeps the filebrowser of the GameDefinition for a profile that leaves it unset", func() {
+		gs.Spec.Profile = "fabric"
+		Expect(defaulter.Default(ctx, gs)).To(Succeed())
+		Expect(gs.Spec.Filebrowser).To(HaveValue(BeTrue()))
+	})
+
+	It("leaves the GameServer alone when the GameDefinition doesn't exist", func() {
+		gs.Spec.Game = "terraria"
+		Expect(defaulter.Default(ctx, gs)).To(Succeed())
+		Expect(gs.Spec.Inputs).To(BeEmpty())
+		Expect(gs.Spec.Filebrowser).To(BeNil())
+	})
+})